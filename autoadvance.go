@@ -0,0 +1,119 @@
+package itime
+
+import (
+	"runtime"
+	"time"
+)
+
+// AutoAdvanceOption is an option of MockTime.StartAutoAdvance.
+type AutoAdvanceOption struct {
+	// IdleWindow is a real time duration without any use of the MockTime
+	// that is regarded as all goroutines are blocked. Default is 1ms.
+	IdleWindow time.Duration
+	// Horizon is the maximum virtual duration from the start of auto advance.
+	// Timers after that are not fired automatically. Zero means no limit.
+	Horizon time.Duration
+}
+
+type autoAdvancer struct {
+	idleWindow time.Duration
+	limit      time.Time
+	hasLimit   bool
+	stop       chan struct{}
+	finished   chan struct{}
+	goroutine  int64 // ID of the auto advance goroutine; guarded by MockTime.lock
+}
+
+// StartAutoAdvance starts auto advance mode.
+//
+// In this mode, MockTime advances to the next pending timer automatically whenever
+// nobody uses it (creating timers, reading Now(), and so on) for IdleWindow of real time.
+// It means all goroutines are regarded as blocked on timer channels, Sleep or contexts.
+// Long tests with many sleeps finish quickly without calling Advance.
+//
+// Idle detection only sees uses of the MockTime. It can't tell goroutines that are
+// busy on CPU, or blocked on I/O or channels unrelated to the MockTime, from blocked ones.
+// Such goroutines may see time jump while they are working. Make IdleWindow longer in that case.
+func (m *MockTime) StartAutoAdvance(opt AutoAdvanceOption) {
+	if opt.IdleWindow == 0 {
+		opt.IdleWindow = time.Millisecond
+	}
+	m.StopAutoAdvance()
+
+	a := &autoAdvancer{
+		idleWindow: opt.IdleWindow,
+		stop:       make(chan struct{}),
+		finished:   make(chan struct{}),
+	}
+	m.lock.Lock()
+	if opt.Horizon > 0 {
+		a.limit = m.current.Add(opt.Horizon)
+		a.hasLimit = true
+	}
+	m.autoAdvance = a
+	m.lock.Unlock()
+
+	go m.runAutoAdvance(a)
+}
+
+// StopAutoAdvance stops auto advance mode started by StartAutoAdvance.
+//
+// It waits for the auto advance goroutine to finish. If it is called on that goroutine
+// (e.g. from an AfterFunc callback), it returns without waiting; the current step
+// completes and no further step is taken.
+func (m *MockTime) StopAutoAdvance() {
+	m.lock.Lock()
+	a := m.autoAdvance
+	m.autoAdvance = nil
+	m.lock.Unlock()
+	if a != nil {
+		close(a.stop)
+		m.lock.Lock()
+		self := a.goroutine == goroutineID()
+		m.lock.Unlock()
+		if !self {
+			<-a.finished
+		}
+	}
+}
+
+func (m *MockTime) runAutoAdvance(a *autoAdvancer) {
+	defer close(a.finished)
+	ticker := time.NewTicker(a.idleWindow)
+	defer ticker.Stop()
+
+	m.lock.Lock()
+	a.goroutine = goroutineID()
+	seen := m.activity
+	m.lock.Unlock()
+	for {
+		select {
+		case <-ticker.C:
+		case <-a.stop:
+			return
+		}
+		m.lock.Lock()
+		activity := m.activity
+		m.lock.Unlock()
+		if activity != seen {
+			seen = activity
+			continue
+		}
+		next, ok := m.nextDeadline()
+		if !ok || (a.hasLimit && next.After(a.limit)) {
+			continue
+		}
+		m.lock.Lock()
+		if m.autoAdvance != a {
+			m.lock.Unlock()
+			return
+		}
+		m.lock.Unlock()
+		m.advanceTo(next, true)
+		runtime.Gosched()
+
+		m.lock.Lock()
+		seen = m.activity
+		m.lock.Unlock()
+	}
+}
@@ -0,0 +1,150 @@
+package itime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockTime_AutoAdvance(t *testing.T) {
+	start := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	mock := NewMockWith(start)
+	defer mock.Close()
+	mock.StartAutoAdvance(AutoAdvanceOption{})
+
+	realStart := time.Now()
+	for i := 0; i < 100; i++ {
+		mock.Sleep(time.Minute)
+	}
+	assert.Equal(t, start.Add(100*time.Minute), mock.Now())
+	assert.True(t, time.Since(realStart) < 5*time.Second)
+}
+
+func TestMockTime_AutoAdvance_Context(t *testing.T) {
+	mock := NewMock()
+	defer mock.Close()
+	mock.StartAutoAdvance(AutoAdvanceOption{})
+
+	start := mock.Now()
+	ctx, cancel := mock.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	<-ctx.Done()
+	assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+	assert.Equal(t, time.Hour, mock.Now().Sub(start))
+}
+
+func TestMockTime_AutoAdvance_Order(t *testing.T) {
+	mock := NewMock()
+	defer mock.Close()
+
+	events := make(chan string, 10)
+	go func() {
+		mock.Sleep(3 * time.Second)
+		events <- "3s"
+	}()
+	go func() {
+		mock.Sleep(time.Second)
+		events <- "1s"
+	}()
+	go func() {
+		mock.Sleep(2 * time.Second)
+		events <- "2s"
+	}()
+	time.Sleep(10 * time.Millisecond)
+	mock.StartAutoAdvance(AutoAdvanceOption{IdleWindow: 5 * time.Millisecond})
+
+	assert.Equal(t, "1s", <-events)
+	assert.Equal(t, "2s", <-events)
+	assert.Equal(t, "3s", <-events)
+}
+
+func TestMockTime_AutoAdvance_Horizon(t *testing.T) {
+	start := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	mock := NewMockWith(start)
+	defer mock.Close()
+
+	ticker := mock.NewTicker(time.Minute)
+	defer ticker.Stop()
+	mock.StartAutoAdvance(AutoAdvanceOption{Horizon: 10 * time.Minute})
+
+	for i := 1; i <= 10; i++ {
+		assert.Equal(t, start.Add(time.Duration(i)*time.Minute), <-ticker.Chan())
+	}
+	// no more ticks beyond the horizon
+	select {
+	case <-ticker.Chan():
+		t.Fatal("ticker should not fire beyond the horizon")
+	case <-time.After(20 * time.Millisecond):
+	}
+	assert.Equal(t, start.Add(10*time.Minute), mock.Now())
+}
+
+func TestMockTime_StopAutoAdvance(t *testing.T) {
+	start := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	mock := NewMockWith(start)
+	defer mock.Close()
+
+	mock.StartAutoAdvance(AutoAdvanceOption{})
+	mock.StopAutoAdvance()
+
+	timer := mock.NewTimer(time.Second)
+	defer timer.Stop()
+	select {
+	case <-timer.Chan():
+		t.Fatal("timer should not fire after auto advance is stopped")
+	case <-time.After(20 * time.Millisecond):
+	}
+	assert.Equal(t, start, mock.Now())
+}
+
+func TestMockTime_AutoAdvance_CloseInCallback(t *testing.T) {
+	mock := NewMock()
+	mock.StartAutoAdvance(AutoAdvanceOption{})
+
+	closed := make(chan struct{})
+	mock.AfterFunc(time.Second, func() {
+		// runs on the auto advance goroutine; it must not wait for itself
+		mock.Close()
+		close(closed)
+	})
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close called from a timer callback deadlocked")
+	}
+}
+
+func TestMockTime_AutoAdvance_StopDuringCallback(t *testing.T) {
+	mock := NewMock()
+	defer mock.Close()
+	mock.StartAutoAdvance(AutoAdvanceOption{})
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	mock.AfterFunc(time.Second, func() {
+		close(entered)
+		<-release
+	})
+	<-entered
+
+	stopped := make(chan struct{})
+	go func() {
+		// another goroutine must wait for the running step to complete
+		mock.StopAutoAdvance()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("StopAutoAdvance returned while a timer callback was running")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("StopAutoAdvance didn't return after the callback finished")
+	}
+}
@@ -0,0 +1,147 @@
+// Package backoff provides backoff policies and Retry that waits via itime.Time.
+//
+// With itime.MockTime and seeded policies, retry schedules are reproducible and can be asserted exactly.
+package backoff
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff returns delays between attempts.
+//
+// Backoff is stateful and not goroutine-safe. Use a new one for each retry loop.
+type Backoff interface {
+	// Next returns the delay before the next attempt.
+	Next() time.Duration
+	// Reset restarts the sequence of delays.
+	Reset()
+}
+
+type constant time.Duration
+
+func (c constant) Next() time.Duration {
+	return time.Duration(c)
+}
+
+func (c constant) Reset() {
+}
+
+// Constant returns Backoff that always waits d.
+func Constant(d time.Duration) Backoff {
+	return constant(d)
+}
+
+type linear struct {
+	initial   time.Duration
+	increment time.Duration
+	next      time.Duration
+}
+
+func (l *linear) Next() time.Duration {
+	d := l.next
+	l.next = addDuration(l.next, l.increment)
+	return d
+}
+
+func (l *linear) Reset() {
+	l.next = l.initial
+}
+
+// Linear returns Backoff that waits initial, initial+increment, initial+2*increment and so on.
+func Linear(initial, increment time.Duration) Backoff {
+	return &linear{initial: initial, increment: increment, next: initial}
+}
+
+type exponential struct {
+	initial    time.Duration
+	multiplier float64
+	next       time.Duration
+}
+
+func (e *exponential) Next() time.Duration {
+	d := e.next
+	e.next = mulDuration(e.next, e.multiplier)
+	return d
+}
+
+func (e *exponential) Reset() {
+	e.next = e.initial
+}
+
+// Exponential returns Backoff that waits initial and multiplies the delay by multiplier each time.
+func Exponential(initial time.Duration, multiplier float64) Backoff {
+	return &exponential{initial: initial, multiplier: multiplier, next: initial}
+}
+
+type decorrelatedJitter struct {
+	base time.Duration
+	max  time.Duration
+	seed int64
+	rand *rand.Rand
+	prev time.Duration
+}
+
+func (d *decorrelatedJitter) Next() time.Duration {
+	upper := mulDuration(d.prev, 3)
+	next := d.base
+	if upper > d.base {
+		next += time.Duration(d.rand.Int63n(int64(upper - d.base)))
+	}
+	if next > d.max {
+		next = d.max
+	}
+	d.prev = next
+	return next
+}
+
+func (d *decorrelatedJitter) Reset() {
+	d.rand = rand.New(rand.NewSource(d.seed))
+	d.prev = d.base
+}
+
+// DecorrelatedJitter returns Backoff of "decorrelated jitter" that waits a random duration
+// between base and three times the previous delay, capped by max.
+//
+// The random generator is seeded by seed, so the same seed produces the same delays.
+func DecorrelatedJitter(base, max time.Duration, seed int64) Backoff {
+	d := &decorrelatedJitter{base: base, max: max, seed: seed}
+	d.Reset()
+	return d
+}
+
+type capped struct {
+	Backoff
+	max time.Duration
+}
+
+func (c capped) Next() time.Duration {
+	d := c.Backoff.Next()
+	if d > c.max {
+		return c.max
+	}
+	return d
+}
+
+// WithCap returns Backoff that limits delays of b to max.
+func WithCap(b Backoff, max time.Duration) Backoff {
+	return capped{Backoff: b, max: max}
+}
+
+// addDuration adds durations without overflow.
+func addDuration(a, b time.Duration) time.Duration {
+	if b > 0 && a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	return a + b
+}
+
+// mulDuration multiplies the duration without overflow.
+func mulDuration(d time.Duration, f float64) time.Duration {
+	v := float64(d) * f
+	if v >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(v)
+}
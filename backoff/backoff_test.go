@@ -0,0 +1,55 @@
+package backoff
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func delays(b Backoff, n int) []time.Duration {
+	var result []time.Duration
+	for i := 0; i < n; i++ {
+		result = append(result, b.Next())
+	}
+	return result
+}
+
+func TestPolicies(t *testing.T) {
+	ms := time.Millisecond
+	assert.Equal(t, []time.Duration{100 * ms, 100 * ms, 100 * ms}, delays(Constant(100*ms), 3))
+	assert.Equal(t, []time.Duration{100 * ms, 150 * ms, 200 * ms}, delays(Linear(100*ms, 50*ms), 3))
+	assert.Equal(t, []time.Duration{100 * ms, 200 * ms, 400 * ms, 800 * ms}, delays(Exponential(100*ms, 2), 4))
+	assert.Equal(t, []time.Duration{100 * ms, 200 * ms, 300 * ms, 300 * ms}, delays(WithCap(Exponential(100*ms, 2), 300*ms), 4))
+
+	b := Exponential(100*ms, 2)
+	delays(b, 3)
+	b.Reset()
+	assert.Equal(t, 100*ms, b.Next())
+}
+
+func TestExponential_Overflow(t *testing.T) {
+	b := Exponential(time.Hour, 1000)
+	result := delays(b, 10)
+	assert.Equal(t, time.Duration(math.MaxInt64), result[9])
+}
+
+func TestDecorrelatedJitter(t *testing.T) {
+	base := 100 * time.Millisecond
+	max := 2 * time.Second
+	b := DecorrelatedJitter(base, max, 1)
+	first := delays(b, 20)
+	prev := base
+	for _, d := range first {
+		assert.True(t, d >= base && d <= max, "%v", d)
+		assert.True(t, d <= 3*prev, "%v", d)
+		prev = d
+	}
+
+	// same seed reproduces the same delays
+	assert.Equal(t, first, delays(DecorrelatedJitter(base, max, 1), 20))
+	b.Reset()
+	assert.Equal(t, first, delays(b, 20))
+	assert.NotEqual(t, first, delays(DecorrelatedJitter(base, max, 2), 20))
+}
@@ -0,0 +1,107 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shibukawa/itime"
+)
+
+// ErrMaxElapsed is returned (wrapping the last error) when the next attempt would start after MaxElapsed.
+var ErrMaxElapsed = errors.New("backoff: max elapsed time exceeded")
+
+// ErrMaxAttempts is returned (wrapping the last error) when all attempts failed.
+var ErrMaxAttempts = errors.New("backoff: max attempts exceeded")
+
+// Option is an option for Retry.
+type Option struct {
+	// Time is a clock to wait between attempts. Default is itime.New().
+	Time itime.Time
+	// Backoff is a policy of delays. Default is Exponential(100ms, 2) capped by 10s.
+	Backoff Backoff
+	// MaxElapsed stops retrying if the next attempt would start after MaxElapsed from the first one. Zero means no limit.
+	MaxElapsed time.Duration
+	// MaxAttempts limits the number of attempts including the first one. Zero means no limit.
+	MaxAttempts int
+	// Notify is called with the error and the delay before each retry.
+	Notify func(err error, delay time.Duration)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (p *permanentError) Error() string {
+	return p.err.Error()
+}
+
+func (p *permanentError) Unwrap() error {
+	return p.err
+}
+
+// Permanent wraps err to stop Retry. Retry returns err without wrapping.
+// If f wraps the result of Permanent further (e.g. by fmt.Errorf with %w), Retry returns it as is.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Retry calls f until it succeeds, returns Permanent error, or retrying is given up.
+//
+// Between attempts, it waits for delays of opt.Backoff via opt.Time. If ctx is done
+// (e.g. a deadline created by Time.WithTimeout expires), it returns ctx's error wrapping the last error.
+// When MaxElapsed or MaxAttempts is exceeded, it returns ErrMaxElapsed or ErrMaxAttempts wrapping the last error.
+func Retry(ctx context.Context, opt Option, f func(ctx context.Context) error) error {
+	if opt.Time == nil {
+		opt.Time = itime.New()
+	}
+	if opt.Backoff == nil {
+		opt.Backoff = WithCap(Exponential(100*time.Millisecond, 2), 10*time.Second)
+	}
+	start := opt.Time.Now()
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := f(ctx)
+		if err == nil {
+			return nil
+		}
+		if permanent, ok := err.(*permanentError); ok {
+			return permanent.err
+		}
+		if errors.As(err, new(*permanentError)) {
+			// wrapped by f; keep the context it added
+			return err
+		}
+		if opt.MaxAttempts > 0 && attempt >= opt.MaxAttempts {
+			return fmt.Errorf("%w: %w", ErrMaxAttempts, err)
+		}
+		delay := opt.Backoff.Next()
+		if opt.MaxElapsed > 0 && opt.Time.Now().Add(delay).Sub(start) > opt.MaxElapsed {
+			return fmt.Errorf("%w: %w", ErrMaxElapsed, err)
+		}
+		if opt.Notify != nil {
+			opt.Notify(err, delay)
+		}
+		if ctxErr := wait(ctx, opt.Time, delay); ctxErr != nil {
+			return fmt.Errorf("%w: %w", ctxErr, err)
+		}
+	}
+}
+
+// wait waits for d via t or until ctx is done.
+func wait(ctx context.Context, t itime.Time, d time.Duration) error {
+	timer := t.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.Chan():
+	case <-ctx.Done():
+	}
+	// the deadline may expire at the same time as the timer
+	return ctx.Err()
+}
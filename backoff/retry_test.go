@@ -0,0 +1,139 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+var errTemporary = errors.New("temporary")
+
+func TestRetry(t *testing.T) {
+	start := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	mock := itime.NewMockWith(start)
+	defer mock.Close()
+	mock.StartAutoAdvance(itime.AutoAdvanceOption{})
+
+	var attempts []time.Duration
+	err := Retry(context.Background(), Option{
+		Time:    mock,
+		Backoff: Exponential(time.Second, 2),
+	}, func(ctx context.Context) error {
+		attempts = append(attempts, mock.Now().Sub(start))
+		if len(attempts) < 4 {
+			return errTemporary
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Duration{0, time.Second, 3 * time.Second, 7 * time.Second}, attempts)
+}
+
+func TestRetry_Limits(t *testing.T) {
+	testcases := []struct {
+		name     string
+		opt      Option
+		expected error
+		attempts int
+	}{
+		{"max attempts", Option{MaxAttempts: 3}, ErrMaxAttempts, 3},
+		// delays are 1s, 2s, 4s: the 4th attempt at 7s exceeds 5s
+		{"max elapsed", Option{MaxElapsed: 5 * time.Second}, ErrMaxElapsed, 3},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			mock := itime.NewMock()
+			defer mock.Close()
+			mock.StartAutoAdvance(itime.AutoAdvanceOption{})
+
+			opt := testcase.opt
+			opt.Time = mock
+			opt.Backoff = Exponential(time.Second, 2)
+			attempts := 0
+			err := Retry(context.Background(), opt, func(ctx context.Context) error {
+				attempts++
+				return errTemporary
+			})
+			assert.True(t, errors.Is(err, testcase.expected))
+			assert.True(t, errors.Is(err, errTemporary))
+			assert.Equal(t, testcase.attempts, attempts)
+		})
+	}
+}
+
+func TestRetry_Permanent(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+
+	attempts := 0
+	err := Retry(context.Background(), Option{Time: mock}, func(ctx context.Context) error {
+		attempts++
+		return Permanent(errTemporary)
+	})
+	assert.Equal(t, errTemporary, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetry_WrappedPermanent(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+
+	attempts := 0
+	err := Retry(context.Background(), Option{Time: mock}, func(ctx context.Context) error {
+		attempts++
+		return fmt.Errorf("fetch: %w", Permanent(errTemporary))
+	})
+	assert.Equal(t, "fetch: "+errTemporary.Error(), err.Error())
+	assert.True(t, errors.Is(err, errTemporary))
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetry_ContextTimeout(t *testing.T) {
+	start := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	mock := itime.NewMockWith(start)
+	defer mock.Close()
+	mock.StartAutoAdvance(itime.AutoAdvanceOption{})
+
+	ctx, cancel := mock.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var attempts []time.Duration
+	err := Retry(ctx, Option{
+		Time:    mock,
+		Backoff: Constant(2 * time.Second),
+	}, func(ctx context.Context) error {
+		attempts = append(attempts, mock.Now().Sub(start))
+		return errTemporary
+	})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, errors.Is(err, errTemporary))
+	assert.Equal(t, []time.Duration{0, 2 * time.Second, 4 * time.Second}, attempts)
+	assert.Equal(t, start.Add(5*time.Second), mock.Now())
+}
+
+func TestRetry_SeededJitter(t *testing.T) {
+	schedule := func() []time.Duration {
+		start := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+		mock := itime.NewMockWith(start)
+		defer mock.Close()
+		mock.StartAutoAdvance(itime.AutoAdvanceOption{})
+
+		var attempts []time.Duration
+		Retry(context.Background(), Option{
+			Time:        mock,
+			Backoff:     DecorrelatedJitter(time.Second, time.Minute, 42),
+			MaxAttempts: 5,
+		}, func(ctx context.Context) error {
+			attempts = append(attempts, mock.Now().Sub(start))
+			return errTemporary
+		})
+		return attempts
+	}
+	first := schedule()
+	assert.Len(t, first, 5)
+	assert.Equal(t, first, schedule())
+}
@@ -0,0 +1,302 @@
+// Package breaker provides a circuit breaker whose cooldown is driven by itime.Time.
+//
+// The transition from open to half-open is fired by a timer, so it can be asserted exactly by advancing itime.MockTime.
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/shibukawa/itime/rolling"
+)
+
+// State is a state of Breaker.
+type State int
+
+const (
+	// Closed lets all calls through and counts failures.
+	Closed State = iota
+	// Open rejects all calls until the cooldown elapses.
+	Open
+	// HalfOpen lets limited probe calls through to decide whether to close or reopen.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+var (
+	// ErrOpen is returned while the breaker is open.
+	ErrOpen = errors.New("breaker: circuit is open")
+	// ErrTooManyProbes is returned while the breaker is half-open and all probe slots are in use.
+	ErrTooManyProbes = errors.New("breaker: too many probes in half-open state")
+)
+
+// Option is an option for New.
+type Option struct {
+	// Time is a clock of cooldown and failure window. Default is itime.New().
+	Time itime.Time
+	// FailureThreshold opens the breaker after this many consecutive failures. Default is 5.
+	FailureThreshold int
+	// FailureRate opens the breaker when the ratio of failures in Window reaches it (0 < FailureRate <= 1).
+	// Zero disables it.
+	FailureRate float64
+	// Window is the length of the window for FailureRate. Default is one minute.
+	Window time.Duration
+	// MinRequests is the minimum number of calls in Window to evaluate FailureRate. Default is 10.
+	MinRequests int
+	// Cooldown is how long the breaker stays open before it becomes half-open. Default is 30 seconds.
+	Cooldown time.Duration
+	// MaxProbes is the maximum number of concurrent probe calls in half-open state. Default is 1.
+	MaxProbes int
+	// SuccessThreshold closes the breaker after this many successful probes. Default is 1.
+	SuccessThreshold int
+	// OnStateChange is called after the state changes.
+	OnStateChange func(from, to State)
+}
+
+// Breaker is a circuit breaker.
+type Breaker struct {
+	time             itime.Time
+	failureThreshold int
+	failureRate      float64
+	minRequests      int
+	cooldown         time.Duration
+	maxProbes        int
+	successThreshold int
+	onStateChange    func(from, to State)
+
+	lock        sync.Mutex
+	state       State
+	gen         int
+	consecutive int
+	requests    *rolling.Counter
+	failures    *rolling.Counter
+	probes      int
+	successes   int
+	timer       itime.Timer
+	openedAt    time.Time
+}
+
+// New creates Breaker in closed state.
+func New(opt Option) *Breaker {
+	if opt.Time == nil {
+		opt.Time = itime.New()
+	}
+	if opt.FailureThreshold <= 0 {
+		opt.FailureThreshold = 5
+	}
+	if opt.Window <= 0 {
+		opt.Window = time.Minute
+	}
+	if opt.MinRequests <= 0 {
+		opt.MinRequests = 10
+	}
+	if opt.Cooldown <= 0 {
+		opt.Cooldown = 30 * time.Second
+	}
+	if opt.MaxProbes <= 0 {
+		opt.MaxProbes = 1
+	}
+	if opt.SuccessThreshold <= 0 {
+		opt.SuccessThreshold = 1
+	}
+	b := &Breaker{
+		time:             opt.Time,
+		failureThreshold: opt.FailureThreshold,
+		failureRate:      opt.FailureRate,
+		minRequests:      opt.MinRequests,
+		cooldown:         opt.Cooldown,
+		maxProbes:        opt.MaxProbes,
+		successThreshold: opt.SuccessThreshold,
+		onStateChange:    opt.OnStateChange,
+	}
+	if opt.FailureRate > 0 {
+		window := rolling.Option{Time: opt.Time, Window: opt.Window, Buckets: 10}
+		b.requests = rolling.NewCounter(window)
+		b.failures = rolling.NewCounter(window)
+	}
+	return b
+}
+
+// State returns current state.
+func (b *Breaker) State() State {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.state
+}
+
+// OpenUntil returns when the open breaker becomes half-open. It returns false if it is not open.
+func (b *Breaker) OpenUntil() (time.Time, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.state != Open {
+		return time.Time{}, false
+	}
+	return b.openedAt.Add(b.cooldown), true
+}
+
+// Allow asks whether a call may go through. If so, call done with the result of the call.
+// Results of calls allowed before the state changed are ignored.
+func (b *Breaker) Allow() (done func(success bool), err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	switch b.state {
+	case Open:
+		return nil, ErrOpen
+	case HalfOpen:
+		if b.probes >= b.maxProbes {
+			return nil, ErrTooManyProbes
+		}
+		b.probes++
+	}
+	gen := b.gen
+	var once sync.Once
+	return func(success bool) {
+		once.Do(func() {
+			b.report(gen, success)
+		})
+	}, nil
+}
+
+// Do calls f if the breaker allows it, and records its result. A non-nil error is a failure.
+func (b *Breaker) Do(f func() error) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	err = f()
+	done(err == nil)
+	return err
+}
+
+// Trip opens the breaker manually.
+func (b *Breaker) Trip() {
+	b.lock.Lock()
+	from, changed := b.setState(Open)
+	b.lock.Unlock()
+	b.notify(from, Open, changed)
+}
+
+// Reset closes the breaker manually and clears failure counts.
+func (b *Breaker) Reset() {
+	b.lock.Lock()
+	from, changed := b.setState(Closed)
+	// setState keeps counters when the breaker is already closed
+	b.consecutive = 0
+	if b.requests != nil {
+		b.requests.Reset()
+		b.failures.Reset()
+	}
+	b.lock.Unlock()
+	b.notify(from, Closed, changed)
+}
+
+func (b *Breaker) report(gen int, success bool) {
+	b.lock.Lock()
+	if gen != b.gen {
+		b.lock.Unlock()
+		return
+	}
+	to := b.state
+	switch b.state {
+	case Closed:
+		if b.requests != nil {
+			b.requests.Incr()
+			if !success {
+				b.failures.Incr()
+			}
+		}
+		if success {
+			b.consecutive = 0
+		} else {
+			b.consecutive++
+		}
+		if b.consecutive >= b.failureThreshold || b.rateExceeded() {
+			to = Open
+		}
+	case HalfOpen:
+		b.probes--
+		if !success {
+			to = Open
+		} else if b.successes++; b.successes >= b.successThreshold {
+			to = Closed
+		}
+	}
+	from, changed := b.setState(to)
+	b.lock.Unlock()
+	b.notify(from, to, changed)
+}
+
+// rateExceeded reports whether the failure rate in the window reaches the threshold. Caller must hold b.lock.
+func (b *Breaker) rateExceeded() bool {
+	if b.requests == nil {
+		return false
+	}
+	requests := b.requests.Sum()
+	if requests < int64(b.minRequests) {
+		return false
+	}
+	return float64(b.failures.Sum())/float64(requests) >= b.failureRate
+}
+
+// setState changes the state and resets counters of the new state. Caller must hold b.lock.
+func (b *Breaker) setState(to State) (State, bool) {
+	from := b.state
+	if from == to {
+		return from, false
+	}
+	b.state = to
+	b.gen++
+	b.consecutive = 0
+	b.probes = 0
+	b.successes = 0
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	switch to {
+	case Closed:
+		if b.requests != nil {
+			b.requests.Reset()
+			b.failures.Reset()
+		}
+	case Open:
+		b.openedAt = b.time.Now()
+		gen := b.gen
+		b.timer = b.time.AfterFunc(b.cooldown, func() {
+			b.halfOpen(gen)
+		})
+	}
+	return from, true
+}
+
+// halfOpen is called when the cooldown elapses.
+func (b *Breaker) halfOpen(gen int) {
+	b.lock.Lock()
+	if gen != b.gen {
+		b.lock.Unlock()
+		return
+	}
+	b.timer = nil
+	from, changed := b.setState(HalfOpen)
+	b.lock.Unlock()
+	b.notify(from, HalfOpen, changed)
+}
+
+func (b *Breaker) notify(from, to State, changed bool) {
+	if changed && b.onStateChange != nil {
+		b.onStateChange(from, to)
+	}
+}
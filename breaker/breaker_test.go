@@ -0,0 +1,207 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+var errFail = errors.New("fail")
+
+type transitionLog struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func (l *transitionLog) onStateChange(from, to State) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.events = append(l.events, from.String()+"->"+to.String())
+}
+
+func (l *transitionLog) result() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.events
+}
+
+func fail() error {
+	return errFail
+}
+
+func succeed() error {
+	return nil
+}
+
+func TestBreaker_ConsecutiveFailures(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	log := &transitionLog{}
+	b := New(Option{Time: mock, FailureThreshold: 3, Cooldown: 10 * time.Second, OnStateChange: log.onStateChange})
+
+	assert.Equal(t, errFail, b.Do(fail))
+	assert.Equal(t, errFail, b.Do(fail))
+	assert.Nil(t, b.Do(succeed)) // resets consecutive count
+	assert.Equal(t, errFail, b.Do(fail))
+	assert.Equal(t, errFail, b.Do(fail))
+	assert.Equal(t, Closed, b.State())
+	assert.Equal(t, errFail, b.Do(fail))
+	assert.Equal(t, Open, b.State())
+	assert.Equal(t, ErrOpen, b.Do(succeed))
+
+	until, ok := b.OpenUntil()
+	assert.True(t, ok)
+	assert.Equal(t, mock.Now().Add(10*time.Second), until)
+
+	mock.Advance(10*time.Second-time.Nanosecond, true)
+	assert.Equal(t, Open, b.State())
+	mock.Advance(time.Nanosecond, true)
+	assert.Equal(t, HalfOpen, b.State())
+	_, ok = b.OpenUntil()
+	assert.False(t, ok)
+
+	assert.Nil(t, b.Do(succeed))
+	assert.Equal(t, Closed, b.State())
+	assert.Equal(t, []string{"closed->open", "open->half-open", "half-open->closed"}, log.result())
+}
+
+func TestBreaker_HalfOpenFailureReopens(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	log := &transitionLog{}
+	b := New(Option{Time: mock, FailureThreshold: 1, Cooldown: time.Second, OnStateChange: log.onStateChange})
+
+	b.Do(fail)
+	mock.Advance(time.Second, true)
+	assert.Equal(t, HalfOpen, b.State())
+	assert.Equal(t, errFail, b.Do(fail))
+	assert.Equal(t, Open, b.State())
+
+	// cooldown restarts from the probe failure
+	until, _ := b.OpenUntil()
+	assert.Equal(t, mock.Now().Add(time.Second), until)
+	mock.Advance(time.Second, true)
+	assert.Equal(t, HalfOpen, b.State())
+	assert.Equal(t, []string{"closed->open", "open->half-open", "half-open->open", "open->half-open"}, log.result())
+}
+
+func TestBreaker_ProbeLimit(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	b := New(Option{Time: mock, FailureThreshold: 1, Cooldown: time.Second, MaxProbes: 2, SuccessThreshold: 3})
+
+	b.Do(fail)
+	mock.Advance(time.Second, true)
+
+	done1, err := b.Allow()
+	assert.Nil(t, err)
+	done2, err := b.Allow()
+	assert.Nil(t, err)
+	_, err = b.Allow()
+	assert.Equal(t, ErrTooManyProbes, err)
+
+	done1(true)
+	done1(true) // ignored
+	assert.Equal(t, HalfOpen, b.State())
+	done3, err := b.Allow()
+	assert.Nil(t, err)
+	done2(true)
+	assert.Equal(t, HalfOpen, b.State())
+	done3(true)
+	assert.Equal(t, Closed, b.State())
+}
+
+func TestBreaker_StaleResultIgnored(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	b := New(Option{Time: mock, FailureThreshold: 1, Cooldown: time.Second})
+
+	slow, err := b.Allow()
+	assert.Nil(t, err)
+	b.Do(fail)
+	mock.Advance(time.Second, true)
+	assert.Equal(t, HalfOpen, b.State())
+
+	// a success of a call started while closed doesn't count as a probe
+	slow(true)
+	assert.Equal(t, HalfOpen, b.State())
+}
+
+func TestBreaker_FailureRate(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	b := New(Option{Time: mock, FailureThreshold: 100, FailureRate: 0.5, MinRequests: 4, Window: 10 * time.Second})
+
+	b.Do(fail)
+	b.Do(succeed)
+	b.Do(fail)
+	assert.Equal(t, Closed, b.State()) // below MinRequests
+
+	// earlier calls leave the window
+	mock.Advance(10*time.Second, true)
+	b.Do(succeed)
+	b.Do(succeed)
+	b.Do(fail)
+	assert.Equal(t, Closed, b.State()) // below MinRequests again
+	b.Do(fail)
+	assert.Equal(t, Open, b.State()) // 2 of 4
+}
+
+func TestBreaker_TripAndReset(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	log := &transitionLog{}
+	b := New(Option{Time: mock, Cooldown: time.Second, OnStateChange: log.onStateChange})
+
+	b.Trip()
+	b.Trip()
+	assert.Equal(t, Open, b.State())
+	b.Reset()
+	assert.Equal(t, Closed, b.State())
+
+	// the cooldown timer of the reset breaker must not fire
+	mock.Advance(time.Second, true)
+	assert.Equal(t, Closed, b.State())
+	assert.Equal(t, []string{"closed->open", "open->closed"}, log.result())
+}
+
+func TestBreaker_ResetClosed(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	log := &transitionLog{}
+	b := New(Option{Time: mock, FailureThreshold: 3, OnStateChange: log.onStateChange})
+
+	b.Do(fail)
+	b.Do(fail)
+	b.Reset()
+	b.Do(fail)
+	b.Do(fail)
+	assert.Equal(t, Closed, b.State())
+	b.Do(fail)
+	assert.Equal(t, Open, b.State())
+	assert.Equal(t, []string{"closed->open"}, log.result())
+}
+
+func TestBreaker_ResetClosed_FailureRate(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	b := New(Option{Time: mock, FailureRate: 0.5, MinRequests: 4, Window: time.Minute})
+
+	b.Do(fail)
+	b.Do(fail)
+	b.Do(succeed)
+	b.Reset()
+	b.Do(fail)
+	assert.Equal(t, Closed, b.State()) // 1 of 1 after reset, below MinRequests
+}
+
+func TestState_String(t *testing.T) {
+	assert.Equal(t, "closed", Closed.String())
+	assert.Equal(t, "open", Open.String())
+	assert.Equal(t, "half-open", HalfOpen.String())
+	assert.Equal(t, "unknown", State(42).String())
+}
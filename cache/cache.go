@@ -0,0 +1,389 @@
+// Package cache provides a generic in-memory cache whose entries expire by itime.Time.
+//
+// Expiry can be tested exactly by advancing itime.MockTime.
+package cache
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/shibukawa/itime"
+)
+
+// ExpiryMode selects how expired entries are removed.
+type ExpiryMode int
+
+const (
+	// Lazy removes expired entries when they are accessed or swept by the janitor.
+	Lazy ExpiryMode = iota
+	// Active removes each entry by a timer exactly when it expires.
+	Active
+)
+
+// EvictReason tells why an entry is removed.
+type EvictReason int
+
+const (
+	// Expired means that the entry's TTL elapsed.
+	Expired EvictReason = iota
+	// Deleted means that the entry was deleted by Delete or Clear.
+	Deleted
+	// Replaced means that the entry was overwritten by Set.
+	Replaced
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case Expired:
+		return "expired"
+	case Deleted:
+		return "deleted"
+	case Replaced:
+		return "replaced"
+	}
+	return "unknown"
+}
+
+// Option is an option for New.
+type Option[K comparable, V any] struct {
+	// Time is a clock of expiry. Default is itime.New().
+	Time itime.Time
+	// TTL is the default time to live of entries added by Set. Zero means no expiration.
+	TTL time.Duration
+	// Sliding extends the expiry of an entry by its TTL whenever it is read by Get.
+	Sliding bool
+	// Expiry selects how expired entries are removed. Default is Lazy.
+	Expiry ExpiryMode
+	// JanitorInterval is the interval of the janitor that sweeps expired entries in Lazy mode. Zero disables it.
+	JanitorInterval time.Duration
+	// OnEvict is called after an entry is removed. It is not called by Close.
+	OnEvict func(key K, value V, reason EvictReason)
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	ttl     time.Duration
+	expires time.Time
+	// index in expiry heap. -1 if the entry doesn't expire.
+	index int
+}
+
+type eviction[K comparable, V any] struct {
+	key    K
+	value  V
+	reason EvictReason
+}
+
+// Cache is a goroutine-safe map whose entries expire.
+type Cache[K comparable, V any] struct {
+	time    itime.Time
+	ttl     time.Duration
+	sliding bool
+	mode    ExpiryMode
+	onEvict func(key K, value V, reason EvictReason)
+
+	lock    sync.Mutex
+	entries map[K]*entry[K, V]
+	expiry  expiryHeap[K, V]
+	timer   itime.Timer
+	timerAt time.Time
+	janitor itime.Ticker
+	done    chan struct{}
+	closed  bool
+}
+
+// New creates Cache.
+func New[K comparable, V any](opt Option[K, V]) *Cache[K, V] {
+	if opt.Time == nil {
+		opt.Time = itime.New()
+	}
+	c := &Cache[K, V]{
+		time:    opt.Time,
+		ttl:     opt.TTL,
+		sliding: opt.Sliding,
+		mode:    opt.Expiry,
+		onEvict: opt.OnEvict,
+		entries: make(map[K]*entry[K, V]),
+		done:    make(chan struct{}),
+	}
+	if opt.Expiry == Lazy && opt.JanitorInterval > 0 {
+		c.janitor = opt.Time.NewTicker(opt.JanitorInterval)
+		go c.runJanitor()
+	}
+	return c
+}
+
+func (c *Cache[K, V]) runJanitor() {
+	for {
+		select {
+		case <-c.janitor.Chan():
+			c.DeleteExpired()
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// Set adds or replaces the entry with the default TTL.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.ttl)
+}
+
+// SetWithTTL adds or replaces the entry that expires after ttl. Non-positive ttl means no expiration.
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	now := c.time.Now()
+	c.lock.Lock()
+	var evicted []eviction[K, V]
+	if old, ok := c.entries[key]; ok {
+		reason := Replaced
+		if c.expired(old, now) {
+			reason = Expired
+		}
+		c.remove(old)
+		evicted = append(evicted, eviction[K, V]{old.key, old.value, reason})
+	}
+	e := &entry[K, V]{key: key, value: value, ttl: ttl, index: -1}
+	c.entries[key] = e
+	if ttl > 0 {
+		e.expires = now.Add(ttl)
+		heap.Push(&c.expiry, e)
+	}
+	c.reschedule()
+	c.lock.Unlock()
+	c.notify(evicted)
+}
+
+// Get returns the value of the entry. Expired entries are not returned.
+// If Sliding is enabled, it extends the expiry of the entry.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	return c.get(key, c.sliding)
+}
+
+// Peek returns the value of the entry without extending its expiry.
+func (c *Cache[K, V]) Peek(key K) (V, bool) {
+	return c.get(key, false)
+}
+
+func (c *Cache[K, V]) get(key K, slide bool) (V, bool) {
+	now := c.time.Now()
+	c.lock.Lock()
+	e, ok := c.entries[key]
+	if !ok {
+		c.lock.Unlock()
+		var zero V
+		return zero, false
+	}
+	if c.expired(e, now) {
+		c.remove(e)
+		c.reschedule()
+		c.lock.Unlock()
+		c.notify([]eviction[K, V]{{e.key, e.value, Expired}})
+		var zero V
+		return zero, false
+	}
+	if slide && e.index >= 0 {
+		e.expires = now.Add(e.ttl)
+		heap.Fix(&c.expiry, e.index)
+		c.reschedule()
+	}
+	value := e.value
+	c.lock.Unlock()
+	return value, true
+}
+
+// TTL returns the remaining time to live of the entry. It returns false if the entry doesn't exist or never expires.
+func (c *Cache[K, V]) TTL(key K) (time.Duration, bool) {
+	now := c.time.Now()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	e, ok := c.entries[key]
+	if !ok || e.index < 0 || c.expired(e, now) {
+		return 0, false
+	}
+	return e.expires.Sub(now), true
+}
+
+// Delete removes the entry and returns true if it existed.
+func (c *Cache[K, V]) Delete(key K) bool {
+	now := c.time.Now()
+	c.lock.Lock()
+	e, ok := c.entries[key]
+	if !ok {
+		c.lock.Unlock()
+		return false
+	}
+	expired := c.expired(e, now)
+	c.remove(e)
+	c.reschedule()
+	c.lock.Unlock()
+	if expired {
+		c.notify([]eviction[K, V]{{e.key, e.value, Expired}})
+		return false
+	}
+	c.notify([]eviction[K, V]{{e.key, e.value, Deleted}})
+	return true
+}
+
+// Clear removes all entries.
+func (c *Cache[K, V]) Clear() {
+	now := c.time.Now()
+	c.lock.Lock()
+	evicted := make([]eviction[K, V], 0, len(c.entries))
+	for _, e := range c.entries {
+		reason := Deleted
+		if c.expired(e, now) {
+			reason = Expired
+		}
+		evicted = append(evicted, eviction[K, V]{e.key, e.value, reason})
+	}
+	c.entries = make(map[K]*entry[K, V])
+	c.expiry = nil
+	c.reschedule()
+	c.lock.Unlock()
+	c.notify(evicted)
+}
+
+// Len returns the number of entries. In Lazy mode, it may include expired entries that are not removed yet.
+func (c *Cache[K, V]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.entries)
+}
+
+// DeleteExpired removes all expired entries now.
+func (c *Cache[K, V]) DeleteExpired() {
+	now := c.time.Now()
+	c.lock.Lock()
+	evicted := c.popExpired(now)
+	c.reschedule()
+	c.lock.Unlock()
+	c.notify(evicted)
+}
+
+// Close stops the janitor and timers. Entries are kept.
+func (c *Cache[K, V]) Close() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	if c.janitor != nil {
+		c.janitor.Stop()
+		close(c.done)
+	}
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+}
+
+func (c *Cache[K, V]) expired(e *entry[K, V], now time.Time) bool {
+	return e.index >= 0 && !now.Before(e.expires)
+}
+
+// remove removes e from the map and the heap. Caller must hold c.lock.
+func (c *Cache[K, V]) remove(e *entry[K, V]) {
+	delete(c.entries, e.key)
+	if e.index >= 0 {
+		heap.Remove(&c.expiry, e.index)
+	}
+}
+
+// popExpired removes expired entries. Caller must hold c.lock.
+func (c *Cache[K, V]) popExpired(now time.Time) []eviction[K, V] {
+	var evicted []eviction[K, V]
+	for len(c.expiry) > 0 && !now.Before(c.expiry[0].expires) {
+		e := heap.Pop(&c.expiry).(*entry[K, V])
+		delete(c.entries, e.key)
+		evicted = append(evicted, eviction[K, V]{e.key, e.value, Expired})
+	}
+	return evicted
+}
+
+// reschedule sets the timer to the earliest expiry in Active mode. Caller must hold c.lock.
+func (c *Cache[K, V]) reschedule() {
+	if c.mode != Active || c.closed {
+		return
+	}
+	if len(c.expiry) == 0 {
+		if c.timer != nil {
+			c.timer.Stop()
+			c.timer = nil
+		}
+		return
+	}
+	next := c.expiry[0].expires
+	if c.timer != nil {
+		if next.Equal(c.timerAt) {
+			return
+		}
+		c.timer.Stop()
+	}
+	c.timerAt = next
+	c.timer = c.time.AfterFuncAt(next, func() {
+		c.expire(next)
+	})
+}
+
+// expire is called by the timer of Active mode.
+func (c *Cache[K, V]) expire(at time.Time) {
+	now := c.time.Now()
+	if now.Before(at) {
+		now = at
+	}
+	c.lock.Lock()
+	if c.closed || !c.timerAt.Equal(at) {
+		c.lock.Unlock()
+		return
+	}
+	c.timer = nil
+	evicted := c.popExpired(now)
+	c.reschedule()
+	c.lock.Unlock()
+	c.notify(evicted)
+}
+
+func (c *Cache[K, V]) notify(evicted []eviction[K, V]) {
+	if c.onEvict == nil {
+		return
+	}
+	for _, e := range evicted {
+		c.onEvict(e.key, e.value, e.reason)
+	}
+}
+
+// expiryHeap is a min-heap of entries ordered by expiry.
+type expiryHeap[K comparable, V any] []*entry[K, V]
+
+func (h expiryHeap[K, V]) Len() int {
+	return len(h)
+}
+
+func (h expiryHeap[K, V]) Less(i, j int) bool {
+	return h[i].expires.Before(h[j].expires)
+}
+
+func (h expiryHeap[K, V]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiryHeap[K, V]) Push(x any) {
+	e := x.(*entry[K, V])
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *expiryHeap[K, V]) Pop() any {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	e.index = -1
+	*h = old[:n-1]
+	return e
+}
@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+type evictLog struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func (l *evictLog) onEvict(key string, value int, reason EvictReason) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.events = append(l.events, fmt.Sprintf("%s=%d:%s", key, value, reason))
+}
+
+func (l *evictLog) result() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.events
+}
+
+func TestCache_Lazy(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	log := &evictLog{}
+	c := New(Option[string, int]{Time: mock, TTL: time.Minute, OnEvict: log.onEvict})
+	defer c.Close()
+
+	c.Set("a", 1)
+	c.SetWithTTL("b", 2, 2*time.Minute)
+	c.SetWithTTL("forever", 3, 0)
+
+	mock.Advance(59*time.Second, true)
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	remaining, ok := c.TTL("b")
+	assert.True(t, ok)
+	assert.Equal(t, 61*time.Second, remaining)
+
+	mock.Advance(time.Second, true)
+	// lazy mode keeps expired entries until they are accessed
+	assert.Equal(t, 3, c.Len())
+	assert.Empty(t, log.result())
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"a=1:expired"}, log.result())
+
+	mock.Advance(time.Hour, true)
+	c.DeleteExpired()
+	assert.Equal(t, []string{"a=1:expired", "b=2:expired"}, log.result())
+	v, ok = c.Get("forever")
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+}
+
+func TestCache_Active(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	log := &evictLog{}
+	c := New(Option[string, int]{Time: mock, TTL: time.Minute, Expiry: Active, OnEvict: log.onEvict})
+	defer c.Close()
+
+	c.Set("a", 1)
+	mock.Advance(30*time.Second, true)
+	c.Set("b", 2)
+	c.SetWithTTL("c", 3, 10*time.Second)
+
+	mock.Advance(10*time.Second, true)
+	assert.Equal(t, []string{"c=3:expired"}, log.result())
+	mock.Advance(20*time.Second, true)
+	assert.Equal(t, []string{"c=3:expired", "a=1:expired"}, log.result())
+	assert.Equal(t, 1, c.Len())
+
+	// deleted entry doesn't fire
+	c.Delete("b")
+	mock.Advance(time.Minute, true)
+	assert.Equal(t, []string{"c=3:expired", "a=1:expired", "b=2:deleted"}, log.result())
+}
+
+func TestCache_Sliding(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	log := &evictLog{}
+	c := New(Option[string, int]{Time: mock, TTL: time.Minute, Sliding: true, Expiry: Active, OnEvict: log.onEvict})
+	defer c.Close()
+
+	c.Set("a", 1)
+	for i := 0; i < 5; i++ {
+		mock.Advance(50*time.Second, true)
+		_, ok := c.Get("a")
+		assert.True(t, ok)
+	}
+	// Peek doesn't extend expiry
+	mock.Advance(50*time.Second, true)
+	_, ok := c.Peek("a")
+	assert.True(t, ok)
+	mock.Advance(10*time.Second, true)
+	assert.Equal(t, []string{"a=1:expired"}, log.result())
+}
+
+func TestCache_Replace(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	log := &evictLog{}
+	c := New(Option[string, int]{Time: mock, TTL: time.Minute, Expiry: Active, OnEvict: log.onEvict})
+	defer c.Close()
+
+	c.Set("a", 1)
+	mock.Advance(30*time.Second, true)
+	c.Set("a", 2)
+	mock.Advance(30*time.Second, true)
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+	c.Clear()
+	assert.Equal(t, []string{"a=1:replaced", "a=2:deleted"}, log.result())
+}
+
+func TestCache_Janitor(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	log := &evictLog{}
+	c := New(Option[string, int]{Time: mock, TTL: time.Minute, JanitorInterval: 10 * time.Second, OnEvict: log.onEvict})
+	defer c.Close()
+
+	c.Set("a", 1)
+	mock.Advance(time.Minute, true)
+	for i := 0; i < 100 && len(log.result()) == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, []string{"a=1:expired"}, log.result())
+	assert.Equal(t, 0, c.Len())
+}
@@ -0,0 +1,309 @@
+// Package cron provides a job scheduler driven by itime.Time.
+//
+// Because timers are created via the injected itime.Time, schedules can be tested
+// by advancing itime.MockTime instead of waiting for real time.
+package cron
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/shibukawa/itime"
+)
+
+// OverlapPolicy decides what happens when a job is activated while its previous run is still running.
+type OverlapPolicy int
+
+const (
+	// OverlapAllow runs the job concurrently with the previous run.
+	OverlapAllow OverlapPolicy = iota
+	// OverlapSkip drops the activation.
+	OverlapSkip
+	// OverlapQueue runs the job after the previous run finishes.
+	OverlapQueue
+)
+
+// CatchUpPolicy decides how activations missed while the scheduler couldn't run
+// (e.g. the process was suspended or the clock jumped forward) are handled.
+type CatchUpPolicy int
+
+const (
+	// CatchUpOnce runs the job once for all missed activations.
+	CatchUpOnce CatchUpPolicy = iota
+	// CatchUpAll runs the job for each missed activation (up to MaxCatchUp).
+	CatchUpAll
+	// CatchUpSkip drops missed activations.
+	CatchUpSkip
+)
+
+// DefaultMaxCatchUp is the default of JobOption.MaxCatchUp.
+const DefaultMaxCatchUp = 100
+
+// ErrNotFound is returned when the job id is not registered.
+var ErrNotFound = errors.New("cron: job not found")
+
+// Option is an option for New.
+type Option struct {
+	// Time is a clock to drive the scheduler. Default is itime.New().
+	Time itime.Time
+	// Location is a default time zone of cron expressions. Default is time.Local.
+	Location *time.Location
+	// JobOption is a default option of jobs added by Add.
+	JobOption JobOption
+}
+
+// JobOption is an option of each job.
+type JobOption struct {
+	Overlap OverlapPolicy
+	CatchUp CatchUpPolicy
+	// MaxCatchUp limits the number of runs by CatchUpAll. Default is DefaultMaxCatchUp. Negative means no limit.
+	MaxCatchUp int
+	// MissedAfter is how late an activation has to be to be treated as missed. Default is one second.
+	MissedAfter time.Duration
+}
+
+// JobID identifies a job in Scheduler.
+type JobID int
+
+type job struct {
+	id       JobID
+	schedule Schedule
+	f        func()
+	opt      JobOption
+	next     time.Time
+	timer    itime.Timer
+	running  int
+	queued   int
+	removed  bool
+}
+
+// Scheduler runs jobs according to their schedules.
+type Scheduler struct {
+	time     itime.Time
+	location *time.Location
+	opt      JobOption
+
+	lock    sync.Mutex
+	jobs    map[JobID]*job
+	lastID  JobID
+	started bool
+	wg      sync.WaitGroup
+}
+
+// New creates Scheduler. Call Start to run jobs.
+func New(opt Option) *Scheduler {
+	if opt.Time == nil {
+		opt.Time = itime.New()
+	}
+	if opt.Location == nil {
+		opt.Location = time.Local
+	}
+	return &Scheduler{
+		time:     opt.Time,
+		location: opt.Location,
+		opt:      opt.JobOption,
+		jobs:     make(map[JobID]*job),
+	}
+}
+
+// Add registers f to run by cron expression spec with the default JobOption.
+func (s *Scheduler) Add(spec string, f func()) (JobID, error) {
+	return s.AddWith(spec, f, s.opt)
+}
+
+// AddWith registers f to run by cron expression spec with opt.
+func (s *Scheduler) AddWith(spec string, f func(), opt JobOption) (JobID, error) {
+	schedule, err := ParseIn(spec, s.location)
+	if err != nil {
+		return 0, err
+	}
+	return s.AddSchedule(schedule, f, opt), nil
+}
+
+// AddSchedule registers f to run by schedule with opt.
+func (s *Scheduler) AddSchedule(schedule Schedule, f func(), opt JobOption) JobID {
+	if opt.MissedAfter == 0 {
+		opt.MissedAfter = time.Second
+	}
+	if opt.MaxCatchUp == 0 {
+		opt.MaxCatchUp = DefaultMaxCatchUp
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.lastID++
+	j := &job{
+		id:       s.lastID,
+		schedule: schedule,
+		f:        f,
+		opt:      opt,
+	}
+	s.jobs[j.id] = j
+	if s.started {
+		s.arm(j, s.time.Now())
+	}
+	return j.id
+}
+
+// Remove unregisters the job. Running job is not interrupted.
+func (s *Scheduler) Remove(id JobID) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	j.removed = true
+	if j.timer != nil {
+		j.timer.Stop()
+	}
+	delete(s.jobs, id)
+	return nil
+}
+
+// Next returns the next activation time of the job. It returns false if the scheduler is not started.
+func (s *Scheduler) Next(id JobID) (time.Time, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	j, ok := s.jobs[id]
+	if !ok || j.timer == nil {
+		return time.Time{}, false
+	}
+	return j.next, true
+}
+
+// Start starts to schedule jobs from current time.
+func (s *Scheduler) Start() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	now := s.time.Now()
+	for _, j := range s.jobs {
+		s.arm(j, now)
+	}
+}
+
+// Stop stops scheduling. Running and queued runs are not interrupted. Use Wait to wait for them.
+func (s *Scheduler) Stop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.started = false
+	for _, j := range s.jobs {
+		if j.timer != nil {
+			j.timer.Stop()
+			j.timer = nil
+		}
+	}
+}
+
+// Wait waits for running jobs to finish.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+// arm sets the timer for the next activation after from. Caller must hold s.lock.
+func (s *Scheduler) arm(j *job, from time.Time) {
+	j.next = j.schedule.Next(from)
+	if j.next.IsZero() {
+		j.timer = nil
+		return
+	}
+	j.timer = s.time.AfterFuncAt(j.next, func() {
+		s.activate(j)
+	})
+}
+
+// activate runs the job for activations up to now and arms the next one.
+func (s *Scheduler) activate(j *job) {
+	now := s.time.Now()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if j.removed || !s.started {
+		return
+	}
+	runs, missed, last := j.activations(now)
+	switch j.opt.CatchUp {
+	case CatchUpOnce:
+		if runs > 1 {
+			runs = 1
+		}
+	case CatchUpAll:
+		if j.opt.MaxCatchUp > 0 && runs > j.opt.MaxCatchUp {
+			runs = j.opt.MaxCatchUp
+		}
+	case CatchUpSkip:
+		if runs-missed > 0 {
+			runs = 1
+		} else {
+			runs = 0
+		}
+	}
+	for i := 0; i < runs; i++ {
+		s.run(j)
+	}
+	s.arm(j, last)
+}
+
+// activations counts activations from j.next up to now, the ones missed by MissedAfter among them,
+// and returns the last one.
+func (j *job) activations(now time.Time) (runs, missed int, last time.Time) {
+	last = j.next
+	if e, ok := j.schedule.(everySchedule); ok && e > 0 {
+		// count arithmetically not to walk through millions of short intervals
+		if now.Before(j.next) {
+			return 0, 0, last
+		}
+		d := time.Duration(e)
+		n := int64(now.Sub(j.next)/d) + 1
+		last = j.next.Add(time.Duration(n-1) * d)
+		var late int64
+		if limit := now.Add(-j.opt.MissedAfter); limit.After(j.next) {
+			// activations before limit are missed
+			late = int64((limit.Sub(j.next) + d - 1) / d)
+			if late > n {
+				late = n
+			}
+		}
+		return int(n), int(late), last
+	}
+	for t := j.next; !t.IsZero() && !t.After(now); t = j.schedule.Next(t) {
+		last = t
+		runs++
+		if now.Sub(t) > j.opt.MissedAfter {
+			missed++
+		}
+	}
+	return runs, missed, last
+}
+
+// run starts the job according to its overlap policy. Caller must hold s.lock.
+func (s *Scheduler) run(j *job) {
+	if j.running > 0 {
+		switch j.opt.Overlap {
+		case OverlapSkip:
+			return
+		case OverlapQueue:
+			j.queued++
+			return
+		}
+	}
+	j.running++
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			j.f()
+			s.lock.Lock()
+			if j.queued == 0 {
+				j.running--
+				s.lock.Unlock()
+				return
+			}
+			j.queued--
+			s.lock.Unlock()
+		}
+	}()
+}
@@ -0,0 +1,202 @@
+package cron
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler_TimeZone(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	mock := itime.NewMockWith(start)
+	defer mock.Close()
+
+	s := New(Option{Time: mock, Location: time.UTC})
+	var count int32
+	id, err := s.Add("CRON_TZ=Asia/Tokyo 0 2 * * *", func() {
+		atomic.AddInt32(&count, 1)
+	})
+	assert.NoError(t, err)
+	s.Start()
+	defer s.Stop()
+
+	next, ok := s.Next(id)
+	assert.True(t, ok)
+	assert.True(t, next.Equal(time.Date(2024, 3, 1, 17, 0, 0, 0, time.UTC)))
+
+	mock.Advance(72*time.Hour, true)
+	s.Wait()
+	assert.Equal(t, int32(3), atomic.LoadInt32(&count))
+	next, _ = s.Next(id)
+	assert.True(t, next.Equal(time.Date(2024, 3, 4, 17, 0, 0, 0, time.UTC)))
+}
+
+func TestScheduler_CatchUp(t *testing.T) {
+	testcases := []struct {
+		name     string
+		policy   CatchUpPolicy
+		max      int
+		expected int32
+	}{
+		{"once", CatchUpOnce, 0, 1},
+		{"all", CatchUpAll, 0, 3},
+		{"all with limit", CatchUpAll, 2, 2},
+		{"skip", CatchUpSkip, 0, 0},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			mock := itime.NewMockWith(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+			defer mock.Close()
+			// the scheduler wakes up 150 minutes late like a suspended process
+			late := itime.NewLatency(mock, itime.LatencyOption{Distribution: itime.FixedLatency(150 * time.Minute)})
+
+			s := New(Option{Time: late})
+			var count int32
+			s.AddSchedule(Every(time.Hour), func() {
+				atomic.AddInt32(&count, 1)
+			}, JobOption{CatchUp: testcase.policy, MaxCatchUp: testcase.max})
+			s.Start()
+			defer s.Stop()
+
+			mock.Advance(3*time.Hour+40*time.Minute, true)
+			s.Wait()
+			assert.Equal(t, testcase.expected, atomic.LoadInt32(&count))
+		})
+	}
+}
+
+func TestScheduler_Overlap(t *testing.T) {
+	testcases := []struct {
+		name            string
+		policy          OverlapPolicy
+		expectedRunning int32
+		expectedTotal   int32
+	}{
+		{"allow", OverlapAllow, 3, 3},
+		{"skip", OverlapSkip, 1, 1},
+		{"queue", OverlapQueue, 1, 3},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			mock := itime.NewMockWith(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+			defer mock.Close()
+
+			s := New(Option{Time: mock, JobOption: JobOption{Overlap: testcase.policy}})
+			release := make(chan struct{})
+			var running, maxRunning, total int32
+			_, err := s.Add("@every 1m", func() {
+				r := atomic.AddInt32(&running, 1)
+				for {
+					m := atomic.LoadInt32(&maxRunning)
+					if r <= m || atomic.CompareAndSwapInt32(&maxRunning, m, r) {
+						break
+					}
+				}
+				<-release
+				atomic.AddInt32(&total, 1)
+				atomic.AddInt32(&running, -1)
+			})
+			assert.NoError(t, err)
+			s.Start()
+
+			mock.Advance(3*time.Minute, true)
+			s.Stop()
+			for atomic.LoadInt32(&running) < testcase.expectedRunning {
+				time.Sleep(time.Millisecond)
+			}
+			close(release)
+			s.Wait()
+			assert.Equal(t, testcase.expectedRunning, atomic.LoadInt32(&maxRunning))
+			assert.Equal(t, testcase.expectedTotal, atomic.LoadInt32(&total))
+		})
+	}
+}
+
+func TestScheduler_Remove(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+
+	s := New(Option{Time: mock})
+	var count int32
+	id, err := s.Add("@every 1s", func() {
+		atomic.AddInt32(&count, 1)
+	})
+	assert.NoError(t, err)
+	s.Start()
+	mock.Advance(time.Second, true)
+	assert.NoError(t, s.Remove(id))
+	assert.Equal(t, ErrNotFound, s.Remove(id))
+	mock.Advance(time.Second, true)
+	s.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+}
+
+func TestScheduler_ScaledTime(t *testing.T) {
+	// ScaledTime must not fire timers on goroutines that arm them under a lock
+	scaled := itime.NewScaled(10000)
+	defer scaled.Close()
+
+	s := New(Option{Time: scaled})
+	var count int32
+	for i := 0; i < 2; i++ {
+		_, err := s.Add("@every 1ms", func() {
+			atomic.AddInt32(&count, 1)
+		})
+		assert.NoError(t, err)
+	}
+	done := make(chan struct{})
+	go func() {
+		s.Start()
+		for atomic.LoadInt32(&count) < 10 {
+			time.Sleep(time.Millisecond)
+		}
+		s.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduler deadlocked")
+	}
+}
+
+// walkSchedule hides everySchedule so that activations are walked one by one.
+type walkSchedule struct {
+	Schedule
+}
+
+func TestJob_Activations(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, late := range []time.Duration{0, 500 * time.Millisecond, time.Second, 1500 * time.Millisecond, 10 * time.Second, 10*time.Second + 1} {
+		every := &job{schedule: Every(time.Second), next: start, opt: JobOption{MissedAfter: 2 * time.Second}}
+		walk := &job{schedule: walkSchedule{Every(time.Second)}, next: start, opt: every.opt}
+		now := start.Add(late)
+		runs, missed, last := every.activations(now)
+		expectedRuns, expectedMissed, expectedLast := walk.activations(now)
+		assert.Equal(t, expectedRuns, runs, late)
+		assert.Equal(t, expectedMissed, missed, late)
+		assert.True(t, expectedLast.Equal(last), late)
+	}
+}
+
+func TestScheduler_CatchUpLimit(t *testing.T) {
+	mock := itime.NewMockWith(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	defer mock.Close()
+	// a whole day of millisecond activations is missed at once
+	late := itime.NewLatency(mock, itime.LatencyOption{Distribution: itime.FixedLatency(24 * time.Hour)})
+
+	s := New(Option{Time: late})
+	var count int32
+	s.AddSchedule(Every(time.Millisecond), func() {
+		atomic.AddInt32(&count, 1)
+	}, JobOption{CatchUp: CatchUpAll})
+	s.Start()
+	defer s.Stop()
+
+	mock.Advance(25*time.Hour, true)
+	s.Wait()
+	assert.Equal(t, int32(DefaultMaxCatchUp), atomic.LoadInt32(&count))
+}
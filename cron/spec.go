@@ -0,0 +1,356 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns activation times of a job.
+type Schedule interface {
+	// Next returns the earliest activation time after t. It returns zero time if there is no activation.
+	Next(t time.Time) time.Time
+}
+
+// Parse parses cron expression in local time zone.
+//
+// See ParseIn for the syntax.
+func Parse(spec string) (Schedule, error) {
+	return ParseIn(spec, time.Local)
+}
+
+// ParseIn parses cron expression. Times are evaluated in loc unless the expression specifies time zone.
+//
+// Supported expressions are:
+//
+//	5 fields:  minute hour day-of-month month day-of-week
+//	6 fields:  second minute hour day-of-month month day-of-week
+//	@yearly (@annually), @monthly, @weekly, @daily (@midnight), @hourly
+//	@every <duration> (e.g. @every 1h30m)
+//
+// Each field accepts *, ?, values, ranges (a-b), steps (*/n, a-b/n, a/n) and lists of them separated by commas.
+// Months and days of week accept names like JAN and SUN. Day of week 7 is Sunday too.
+// If both day of month and day of week are restricted, a day matching either runs the job like standard cron.
+// Expressions can be prefixed with CRON_TZ=<zone> or TZ=<zone> to specify time zone.
+func ParseIn(spec string, loc *time.Location) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		i := strings.IndexAny(spec, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("cron: missing expression after time zone: %q", spec)
+		}
+		name := spec[strings.IndexByte(spec, '=')+1 : i]
+		var err error
+		loc, err = time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("cron: invalid time zone %q: %w", name, err)
+		}
+		spec = strings.TrimSpace(spec[i:])
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	if strings.HasPrefix(spec, "@") {
+		return parseDescriptor(spec, loc)
+	}
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("cron: expected 5 or 6 fields, found %d: %q", len(fields), spec)
+	}
+	s := &specSchedule{loc: loc}
+	var err error
+	if s.second, err = parseField(fields[0], seconds); err != nil {
+		return nil, err
+	}
+	if s.minute, err = parseField(fields[1], minutes); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[2], hours); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseField(fields[3], daysOfMonth); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[4], months); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseField(fields[5], daysOfWeek); err != nil {
+		return nil, err
+	}
+	// 7 is an alias of Sunday
+	if s.dow.has(7) {
+		s.dow |= 1
+	}
+	s.domAny = isWildcard(fields[3])
+	s.dowAny = isWildcard(fields[5])
+	return s, nil
+}
+
+// MustParse is like Parse but panics if spec is invalid.
+func MustParse(spec string) Schedule {
+	s, err := Parse(spec)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func parseDescriptor(spec string, loc *time.Location) (Schedule, error) {
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(spec[len("@every "):]))
+		if err != nil {
+			return nil, fmt.Errorf("cron: invalid duration in %q: %w", spec, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("cron: non-positive duration in %q", spec)
+		}
+		return Every(d), nil
+	}
+	expr, ok := descriptors[spec]
+	if !ok {
+		return nil, fmt.Errorf("cron: unknown descriptor %q", spec)
+	}
+	return ParseIn(expr, loc)
+}
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// Every returns a Schedule that activates every d.
+func Every(d time.Duration) Schedule {
+	return everySchedule(d)
+}
+
+type everySchedule time.Duration
+
+func (e everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+type bounds struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	seconds     = bounds{0, 59, nil}
+	minutes     = bounds{0, 59, nil}
+	hours       = bounds{0, 23, nil}
+	daysOfMonth = bounds{1, 31, nil}
+	months      = bounds{1, 12, map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	daysOfWeek = bounds{0, 7, map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// bitset has a bit for each allowed value of a field.
+type bitset uint64
+
+func (b bitset) has(v int) bool {
+	return b&(1<<uint(v)) != 0
+}
+
+func isWildcard(field string) bool {
+	return field == "*" || field == "?"
+}
+
+func parseField(field string, r bounds) (bitset, error) {
+	var result bitset
+	for _, expr := range strings.Split(field, ",") {
+		bits, err := parseRange(expr, r)
+		if err != nil {
+			return 0, err
+		}
+		result |= bits
+	}
+	return result, nil
+}
+
+func parseRange(expr string, r bounds) (bitset, error) {
+	rangeAndStep := strings.SplitN(expr, "/", 2)
+	lowAndHigh := strings.SplitN(rangeAndStep[0], "-", 2)
+	var low, high int
+	var err error
+	if isWildcard(lowAndHigh[0]) {
+		if len(lowAndHigh) > 1 {
+			return 0, fmt.Errorf("cron: invalid range %q", expr)
+		}
+		low, high = r.min, r.max
+		if r.max == 7 {
+			// Sunday is already covered by 0
+			high = 6
+		}
+	} else {
+		if low, err = parseValue(lowAndHigh[0], r); err != nil {
+			return 0, err
+		}
+		high = low
+		if len(lowAndHigh) > 1 {
+			if high, err = parseValue(lowAndHigh[1], r); err != nil {
+				return 0, err
+			}
+		} else if len(rangeAndStep) > 1 {
+			// a/n means a-max/n
+			high = r.max
+		}
+	}
+	step := 1
+	if len(rangeAndStep) > 1 {
+		step, err = strconv.Atoi(rangeAndStep[1])
+		if err != nil || step <= 0 {
+			return 0, fmt.Errorf("cron: invalid step %q", expr)
+		}
+	}
+	if low > high {
+		return 0, fmt.Errorf("cron: invalid range %q", expr)
+	}
+	var bits bitset
+	for v := low; v <= high; v += step {
+		bits |= 1 << uint(v)
+	}
+	return bits, nil
+}
+
+func parseValue(value string, r bounds) (int, error) {
+	if v, ok := r.names[strings.ToLower(value)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("cron: invalid value %q", value)
+	}
+	if v < r.min || v > r.max {
+		return 0, fmt.Errorf("cron: value %d out of range [%d, %d]", v, r.min, r.max)
+	}
+	return v, nil
+}
+
+// specSchedule is a Schedule of cron expression.
+//
+// dayMatches and Next are derived from github.com/robfig/cron (MIT License,
+// Copyright (C) 2012 Rob Figueiredo). See CREDITS.
+type specSchedule struct {
+	second, minute, hour, dom, month, dow bitset
+	domAny, dowAny                        bool
+	loc                                   *time.Location
+}
+
+func (s *specSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom.has(t.Day())
+	dowMatch := s.dow.has(int(t.Weekday()))
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Next returns the earliest activation time after t.
+//
+// It searches field by field from month to second. Times skipped by daylight saving time
+// transitions are not activated, and repeated times are activated once.
+func (s *specSchedule) Next(t time.Time) time.Time {
+	origLoc := t.Location()
+	t = t.In(s.loc)
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	added := false
+	yearLimit := t.Year() + 5
+
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+	for !s.month.has(int(t.Month())) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, s.loc)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+	for !s.dayMatches(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.loc)
+		}
+		t = t.AddDate(0, 0, 1)
+		// midnight may not exist because of daylight saving time
+		if t.Hour() != 0 {
+			if t.Hour() > 12 {
+				t = t.Add(time.Duration(24-t.Hour()) * time.Hour)
+			} else {
+				t = t.Add(-time.Duration(t.Hour()) * time.Hour)
+			}
+		}
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+	for !s.hour.has(t.Hour()) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, s.loc)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+	for !s.minute.has(t.Minute()) {
+		if !added {
+			added = true
+			t = t.Truncate(time.Minute)
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+	for !s.second.has(t.Second()) {
+		if !added {
+			added = true
+			t = t.Truncate(time.Second)
+		}
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto wrap
+		}
+	}
+	if end, ok := repeated(t); ok {
+		// the same wall clock time has been activated before the clock was turned back
+		return s.Next(end.Add(-time.Second).In(origLoc))
+	}
+	return t.In(origLoc)
+}
+
+// repeated reports whether the wall clock time of t has already appeared because the clock was
+// turned back (e.g. at the end of daylight saving time). end is when the repeated period ends.
+func repeated(t time.Time) (end time.Time, ok bool) {
+	start, _ := t.ZoneBounds()
+	if start.IsZero() {
+		return time.Time{}, false
+	}
+	_, offset := t.Zone()
+	_, prevOffset := start.Add(-time.Nanosecond).Zone()
+	if prevOffset <= offset {
+		return time.Time{}, false
+	}
+	end = start.Add(time.Duration(prevOffset-offset) * time.Second)
+	return end, t.Before(end)
+}
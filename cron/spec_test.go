@@ -0,0 +1,66 @@
+package cron
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIn_Next(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.NoError(t, err)
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	testcases := []struct {
+		name     string
+		spec     string
+		loc      *time.Location
+		from     time.Time
+		expected time.Time
+	}{
+		{"every minute", "* * * * *", time.UTC, time.Date(2024, 3, 1, 10, 20, 30, 0, time.UTC), time.Date(2024, 3, 1, 10, 21, 0, 0, time.UTC)},
+		{"6 fields", "*/15 * * * * *", time.UTC, time.Date(2024, 3, 1, 10, 20, 30, 0, time.UTC), time.Date(2024, 3, 1, 10, 20, 45, 0, time.UTC)},
+		{"daily at 2am", "0 2 * * *", time.UTC, time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC), time.Date(2024, 3, 2, 2, 0, 0, 0, time.UTC)},
+		{"list and range", "0 9-10,18 * * *", time.UTC, time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC), time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC)},
+		{"names", "0 0 * FEB MON", time.UTC, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC)},
+		{"dom or dow", "0 0 13 * 5", time.UTC, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"sunday as 7", "0 0 * * 7", time.UTC, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", time.UTC, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"monthly", "@monthly", time.UTC, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"every", "@every 90m", time.UTC, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 1, 30, 0, 0, time.UTC)},
+		{"time zone", "CRON_TZ=Asia/Tokyo 0 2 * * *", time.UTC, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 17, 0, 0, 0, time.UTC)},
+		{"result in location of from", "TZ=Asia/Tokyo @daily", time.UTC, time.Date(2024, 3, 1, 0, 0, 0, 0, tokyo), time.Date(2024, 3, 2, 0, 0, 0, 0, tokyo)},
+		{"skipped by DST", "30 2 * * *", newYork, time.Date(2024, 3, 10, 0, 0, 0, 0, newYork), time.Date(2024, 3, 11, 2, 30, 0, 0, newYork)},
+		{"before fall-back", "30 1 * * *", newYork, time.Date(2024, 11, 3, 0, 0, 0, 0, newYork), time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC)},
+		{"repeated by fall-back", "30 1 * * *", newYork, time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC), time.Date(2024, 11, 4, 1, 30, 0, 0, newYork)},
+		{"hourly over fall-back", "0 * * * *", newYork, time.Date(2024, 11, 3, 5, 0, 0, 0, time.UTC), time.Date(2024, 11, 3, 7, 0, 0, 0, time.UTC)},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			schedule, err := ParseIn(testcase.spec, testcase.loc)
+			assert.NoError(t, err)
+			next := schedule.Next(testcase.from)
+			assert.True(t, testcase.expected.Equal(next), "expected %v, actual %v", testcase.expected, next)
+		})
+	}
+}
+
+func TestParseIn_Error(t *testing.T) {
+	for _, spec := range []string{
+		"* * * *",
+		"60 * * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"@every -1s",
+		"@sometimes",
+		"TZ=Nowhere/City * * * * *",
+	} {
+		_, err := ParseIn(spec, time.UTC)
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,106 @@
+package debounce
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shibukawa/itime"
+)
+
+// BatchOption is an option for NewBatcher.
+type BatchOption struct {
+	// Time is a clock for timers. Default is itime.New().
+	Time itime.Time
+	// MaxSize flushes the batch when it has MaxSize items. Zero means no limit.
+	MaxSize int
+	// MaxWait flushes the batch when MaxWait elapsed since its first item. Zero means no limit.
+	MaxWait time.Duration
+}
+
+// Batcher collects items and passes them to the flush function by size or by time whichever comes first.
+//
+// Flushes are serialized and called in order of batches.
+type Batcher[T any] struct {
+	time    itime.Time
+	maxSize int
+	maxWait time.Duration
+	flush   func(items []T)
+
+	flushLock sync.Mutex
+	lock      sync.Mutex
+	items     []T
+	timer     itime.Timer
+	gen       int
+	closed    bool
+}
+
+// NewBatcher creates Batcher that passes batches to flush.
+func NewBatcher[T any](opt BatchOption, flush func(items []T)) *Batcher[T] {
+	if opt.Time == nil {
+		opt.Time = itime.New()
+	}
+	return &Batcher[T]{
+		time:    opt.Time,
+		maxSize: opt.MaxSize,
+		maxWait: opt.MaxWait,
+		flush:   flush,
+	}
+}
+
+// Add adds an item. If the batch gets full, it is flushed synchronously.
+// Items added after Close are flushed immediately one by one.
+func (b *Batcher[T]) Add(item T) {
+	b.lock.Lock()
+	b.items = append(b.items, item)
+	full := b.closed || (b.maxSize > 0 && len(b.items) >= b.maxSize)
+	if len(b.items) == 1 && !full && b.maxWait > 0 {
+		gen := b.gen
+		b.timer = b.time.AfterFunc(b.maxWait, func() {
+			b.flushBatch(gen)
+		})
+	}
+	gen := b.gen
+	b.lock.Unlock()
+
+	if full {
+		b.flushBatch(gen)
+	}
+}
+
+// Flush flushes the current batch now.
+func (b *Batcher[T]) Flush() {
+	b.lock.Lock()
+	gen := b.gen
+	b.lock.Unlock()
+	b.flushBatch(gen)
+}
+
+// Close flushes the current batch and stops the timer.
+func (b *Batcher[T]) Close() {
+	b.lock.Lock()
+	b.closed = true
+	gen := b.gen
+	b.lock.Unlock()
+	b.flushBatch(gen)
+}
+
+// flushBatch flushes the batch of generation gen if it is not flushed yet.
+func (b *Batcher[T]) flushBatch(gen int) {
+	b.flushLock.Lock()
+	defer b.flushLock.Unlock()
+	b.lock.Lock()
+	if gen != b.gen || len(b.items) == 0 {
+		b.lock.Unlock()
+		return
+	}
+	items := b.items
+	b.items = nil
+	b.gen++
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.lock.Unlock()
+
+	b.flush(items)
+}
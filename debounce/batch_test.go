@@ -0,0 +1,75 @@
+package debounce
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+type batchLog struct {
+	lock    sync.Mutex
+	batches [][]int
+}
+
+func (l *batchLog) flush(items []int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.batches = append(l.batches, items)
+}
+
+func (l *batchLog) result() [][]int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.batches
+}
+
+func TestBatcher(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	log := &batchLog{}
+	b := NewBatcher(BatchOption{Time: mock, MaxSize: 3, MaxWait: time.Second}, log.flush)
+
+	// by size
+	b.Add(1)
+	b.Add(2)
+	b.Add(3)
+	assert.Equal(t, [][]int{{1, 2, 3}}, log.result())
+
+	// by time since the first item
+	b.Add(4)
+	mock.Advance(500*time.Millisecond, true)
+	b.Add(5)
+	mock.Advance(499*time.Millisecond, true)
+	assert.Len(t, log.result(), 1)
+	mock.Advance(time.Millisecond, true)
+	assert.Equal(t, [][]int{{1, 2, 3}, {4, 5}}, log.result())
+
+	// the timer of the flushed batch doesn't flush the next one early
+	b.Add(6)
+	b.Add(7)
+	b.Add(8)
+	b.Add(9)
+	mock.Advance(999*time.Millisecond, true)
+	assert.Len(t, log.result(), 3)
+	mock.Advance(time.Millisecond, true)
+	assert.Equal(t, [][]int{{1, 2, 3}, {4, 5}, {6, 7, 8}, {9}}, log.result())
+}
+
+func TestBatcher_Close(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	log := &batchLog{}
+	b := NewBatcher(BatchOption{Time: mock, MaxWait: time.Second}, log.flush)
+
+	b.Add(1)
+	b.Add(2)
+	b.Flush()
+	b.Add(3)
+	b.Close()
+	b.Add(4)
+	mock.Advance(time.Minute, true)
+	assert.Equal(t, [][]int{{1, 2}, {3}, {4}}, log.result())
+}
@@ -0,0 +1,129 @@
+// Package debounce provides a debouncer, a throttler and a batcher that coalesce events with itime.Time timers.
+//
+// Callbacks fired by timers run in the goroutine that advances itime.MockTime,
+// so their behavior can be verified by stepping a MockTime or driving an itime.Sequence.
+package debounce
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shibukawa/itime"
+)
+
+// Edge selects when Debouncer and Throttler call the function.
+type Edge int
+
+const (
+	// Trailing calls the function at the end of the quiet period (or interval).
+	Trailing Edge = iota
+	// Leading calls the function at the first event and ignores the following events in the period.
+	Leading
+	// Both calls the function at the first event, and at the end of the period if more events happened.
+	Both
+)
+
+func (e Edge) leading() bool {
+	return e == Leading || e == Both
+}
+
+func (e Edge) trailing() bool {
+	return e == Trailing || e == Both
+}
+
+// DebounceOption is an option for NewDebouncer.
+type DebounceOption struct {
+	// Time is a clock for timers. Default is itime.New().
+	Time itime.Time
+	// Wait is the quiet period. Every event restarts it.
+	Wait time.Duration
+	// Edge selects when to call the function. Default is Trailing.
+	Edge Edge
+}
+
+// Debouncer calls the function once for a series of events that are closer than Wait.
+type Debouncer struct {
+	time itime.Time
+	wait time.Duration
+	edge Edge
+	f    func()
+
+	lock    sync.Mutex
+	timer   itime.Timer
+	gen     int
+	pending bool
+}
+
+// NewDebouncer creates Debouncer that calls f.
+func NewDebouncer(opt DebounceOption, f func()) *Debouncer {
+	if opt.Time == nil {
+		opt.Time = itime.New()
+	}
+	return &Debouncer{
+		time: opt.Time,
+		wait: opt.Wait,
+		edge: opt.Edge,
+		f:    f,
+	}
+}
+
+// Trigger notifies an event. The function is called synchronously if it is the leading edge.
+func (d *Debouncer) Trigger() {
+	d.lock.Lock()
+	first := d.timer == nil
+	if first {
+		d.pending = !d.edge.leading()
+	} else {
+		d.timer.Stop()
+		d.pending = true
+	}
+	d.gen++
+	gen := d.gen
+	d.timer = d.time.AfterFunc(d.wait, func() {
+		d.expire(gen)
+	})
+	d.lock.Unlock()
+
+	if first && d.edge.leading() {
+		d.f()
+	}
+}
+
+func (d *Debouncer) expire(gen int) {
+	d.lock.Lock()
+	if gen != d.gen {
+		d.lock.Unlock()
+		return
+	}
+	call := d.pending && d.edge.trailing()
+	d.timer = nil
+	d.pending = false
+	d.lock.Unlock()
+
+	if call {
+		d.f()
+	}
+}
+
+// Flush ends the current period now and calls the function if a trailing call is pending.
+func (d *Debouncer) Flush() {
+	d.lock.Lock()
+	gen := d.gen
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.lock.Unlock()
+	d.expire(gen)
+}
+
+// Stop cancels the pending call.
+func (d *Debouncer) Stop() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	d.gen++
+	d.pending = false
+}
@@ -0,0 +1,101 @@
+package debounce
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+// callLog records virtual times of calls.
+type callLog struct {
+	lock  sync.Mutex
+	start time.Time
+	time  itime.Time
+	calls []time.Duration
+}
+
+func newCallLog(t itime.Time) *callLog {
+	return &callLog{start: t.Now(), time: t}
+}
+
+func (c *callLog) call() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.calls = append(c.calls, c.time.Now().Sub(c.start))
+}
+
+func (c *callLog) result() []time.Duration {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.calls
+}
+
+// triggerAt triggers at each offset from the start, then advances the clock to the end.
+func triggerAt(mock *itime.MockTime, trigger func(), end time.Duration, offsets ...time.Duration) {
+	var elapsed time.Duration
+	for _, offset := range offsets {
+		mock.Advance(offset-elapsed, true)
+		elapsed = offset
+		trigger()
+	}
+	mock.Advance(end-elapsed, true)
+}
+
+func TestDebouncer(t *testing.T) {
+	ms := time.Millisecond
+	testcases := []struct {
+		name     string
+		edge     Edge
+		expected []time.Duration
+	}{
+		// events at 0, 50ms, 100ms and 300ms with 100ms quiet period
+		{"trailing", Trailing, []time.Duration{200 * ms, 400 * ms}},
+		{"leading", Leading, []time.Duration{0, 300 * ms}},
+		{"both", Both, []time.Duration{0, 200 * ms, 300 * ms}},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			mock := itime.NewMock()
+			defer mock.Close()
+			log := newCallLog(mock)
+			d := NewDebouncer(DebounceOption{Time: mock, Wait: 100 * ms, Edge: testcase.edge}, log.call)
+			triggerAt(mock, d.Trigger, time.Second, 0, 50*ms, 100*ms, 300*ms)
+			assert.Equal(t, testcase.expected, log.result())
+		})
+	}
+}
+
+func TestDebouncer_FlushAndStop(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	log := newCallLog(mock)
+	d := NewDebouncer(DebounceOption{Time: mock, Wait: time.Second}, log.call)
+
+	d.Trigger()
+	d.Flush()
+	assert.Equal(t, []time.Duration{0}, log.result())
+
+	d.Trigger()
+	d.Stop()
+	mock.Advance(time.Minute, true)
+	assert.Equal(t, []time.Duration{0}, log.result())
+}
+
+func TestDebouncer_Sequence(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	log := newCallLog(mock)
+	d := NewDebouncer(DebounceOption{Time: mock, Wait: time.Second}, log.call)
+
+	err := itime.NewSequence(itime.Option{Time: mock}).
+		Event(d.Trigger).
+		Wait(500 * time.Millisecond).
+		Event(d.Trigger).
+		Wait(2 * time.Second).
+		Do(func() {})
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Duration{1500 * time.Millisecond}, log.result())
+}
@@ -0,0 +1,105 @@
+package debounce
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shibukawa/itime"
+)
+
+// ThrottleOption is an option for NewThrottler.
+type ThrottleOption struct {
+	// Time is a clock for timers. Default is itime.New().
+	Time itime.Time
+	// Interval is the minimum interval between calls.
+	Interval time.Duration
+	// Edge selects when to call the function. Default is Trailing.
+	Edge Edge
+}
+
+// Throttler calls the function at most once per Interval however often events happen.
+//
+// Unlike Debouncer, events don't extend the interval.
+type Throttler struct {
+	time     itime.Time
+	interval time.Duration
+	edge     Edge
+	f        func()
+
+	lock    sync.Mutex
+	timer   itime.Timer
+	gen     int
+	pending bool
+}
+
+// NewThrottler creates Throttler that calls f.
+func NewThrottler(opt ThrottleOption, f func()) *Throttler {
+	if opt.Time == nil {
+		opt.Time = itime.New()
+	}
+	return &Throttler{
+		time:     opt.Time,
+		interval: opt.Interval,
+		edge:     opt.Edge,
+		f:        f,
+	}
+}
+
+// Trigger notifies an event. The function is called synchronously if it is the leading edge.
+func (t *Throttler) Trigger() {
+	t.lock.Lock()
+	if t.timer != nil {
+		t.pending = true
+		t.lock.Unlock()
+		return
+	}
+	t.pending = !t.edge.leading()
+	t.start()
+	t.lock.Unlock()
+
+	if t.edge.leading() {
+		t.f()
+	}
+}
+
+// start starts new interval. Caller must hold t.lock.
+func (t *Throttler) start() {
+	t.gen++
+	gen := t.gen
+	t.timer = t.time.AfterFunc(t.interval, func() {
+		t.expire(gen)
+	})
+}
+
+func (t *Throttler) expire(gen int) {
+	t.lock.Lock()
+	if gen != t.gen {
+		t.lock.Unlock()
+		return
+	}
+	call := t.pending && t.edge.trailing()
+	t.pending = false
+	if call {
+		// the trailing call starts next interval to keep calls apart
+		t.start()
+	} else {
+		t.timer = nil
+	}
+	t.lock.Unlock()
+
+	if call {
+		t.f()
+	}
+}
+
+// Stop cancels the pending call and ends the current interval.
+func (t *Throttler) Stop() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	t.gen++
+	t.pending = false
+}
@@ -0,0 +1,45 @@
+package debounce
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottler(t *testing.T) {
+	ms := time.Millisecond
+	testcases := []struct {
+		name     string
+		edge     Edge
+		expected []time.Duration
+	}{
+		// events at 0, 50ms, 100ms, 150ms, 300ms and 500ms with 200ms interval
+		{"trailing", Trailing, []time.Duration{200 * ms, 400 * ms, 600 * ms}},
+		{"leading", Leading, []time.Duration{0, 300 * ms, 500 * ms}},
+		{"both", Both, []time.Duration{0, 200 * ms, 400 * ms, 600 * ms}},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			mock := itime.NewMock()
+			defer mock.Close()
+			log := newCallLog(mock)
+			th := NewThrottler(ThrottleOption{Time: mock, Interval: 200 * ms, Edge: testcase.edge}, log.call)
+			triggerAt(mock, th.Trigger, time.Second, 0, 50*ms, 100*ms, 150*ms, 300*ms, 500*ms)
+			assert.Equal(t, testcase.expected, log.result())
+		})
+	}
+}
+
+func TestThrottler_Stop(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	log := newCallLog(mock)
+	th := NewThrottler(ThrottleOption{Time: mock, Interval: time.Second}, log.call)
+
+	th.Trigger()
+	th.Stop()
+	mock.Advance(time.Minute, true)
+	assert.Empty(t, log.result())
+}
@@ -0,0 +1,213 @@
+package itime
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DerivedTime is a Time that shares the timeline of a MockTime, but has its own view of time
+// with fixed skew and drift rate.
+//
+// It is useful to simulate a cluster of nodes whose clocks disagree in a single test process.
+// Advancing the parent MockTime advances all derived clocks, and timers of each derived clock
+// fire according to its own view.
+type DerivedTime struct {
+	mock     *MockTime
+	origin   time.Time
+	skew     time.Duration
+	driftPPM float64
+
+	lock   sync.Mutex
+	timers map[*MockTimer]struct{}
+}
+
+// Derive creates a child clock of the MockTime.
+//
+// The child's Now() is skew ahead of the parent at the time of creation, and the child's clock
+// runs driftPPM parts per million faster (or slower if negative) than the parent after that.
+func (m *MockTime) Derive(skew time.Duration, driftPPM float64) *DerivedTime {
+	if driftPPM <= -1e6 {
+		panic("drift must be greater than -1000000ppm")
+	}
+	return &DerivedTime{
+		mock:     m,
+		origin:   m.now(),
+		skew:     skew,
+		driftPPM: driftPPM,
+		timers:   make(map[*MockTimer]struct{}),
+	}
+}
+
+// Parent returns the MockTime that the clock is derived from.
+func (d *DerivedTime) Parent() *MockTime {
+	return d.mock
+}
+
+// local converts the parent's time to the derived clock's time.
+func (d *DerivedTime) local(t time.Time) time.Time {
+	elapsed := t.Sub(d.origin)
+	return d.origin.Add(d.skew + elapsed + time.Duration(float64(elapsed)*d.driftPPM/1e6))
+}
+
+// parentTime converts the derived clock's time to the parent's time.
+func (d *DerivedTime) parentTime(t time.Time) time.Time {
+	return d.origin.Add(d.parentDuration(t.Sub(d.origin) - d.skew))
+}
+
+// parentDuration converts a duration on the derived clock to a duration on the parent.
+func (d *DerivedTime) parentDuration(dur time.Duration) time.Duration {
+	return time.Duration(float64(dur) / (1 + d.driftPPM/1e6))
+}
+
+// Now returns current time of the derived clock.
+func (d *DerivedTime) Now() time.Time {
+	return d.local(d.mock.now())
+}
+
+// Close stops all timers created via the derived clock.
+func (d *DerivedTime) Close() {
+	d.lock.Lock()
+	timers := d.timers
+	d.timers = make(map[*MockTimer]struct{})
+	d.lock.Unlock()
+	for timer := range timers {
+		timer.Stop()
+	}
+}
+
+func (d *DerivedTime) schedule(r *MockTimer) *derivedTimer {
+	r.d = d.parentDuration(r.d)
+	r.conv = d.local
+	r.expired = func() {
+		d.remove(r)
+	}
+	d.lock.Lock()
+	d.timers[r] = struct{}{}
+	d.lock.Unlock()
+	d.mock.schedule(r)
+	return &derivedTimer{p: d, t: r}
+}
+
+func (d *DerivedTime) remove(r *MockTimer) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	delete(d.timers, r)
+}
+
+// NewTimer creates new oneshot timer that expires after dur on the derived clock.
+func (d *DerivedTime) NewTimer(dur time.Duration) Timer {
+	return d.schedule(&MockTimer{d: dur, oneshot: true})
+}
+
+// NewTicker creates interval timer that ticks every dur on the derived clock.
+func (d *DerivedTime) NewTicker(dur time.Duration) Ticker {
+	if dur <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return d.schedule(&MockTimer{d: dur, oneshot: false})
+}
+
+// AfterFunc waits for dur to elapse on the derived clock and then calls f.
+func (d *DerivedTime) AfterFunc(dur time.Duration, f func()) Timer {
+	return d.schedule(&MockTimer{d: dur, cb: f, oneshot: true})
+}
+
+// After is a shorthand of creating Timer instance.
+func (d *DerivedTime) After(dur time.Duration) <-chan time.Time {
+	return d.NewTimer(dur).Chan()
+}
+
+// Tick is a shorthand of creating Ticker instance.
+func (d *DerivedTime) Tick(dur time.Duration) <-chan time.Time {
+	return d.NewTicker(dur).Chan()
+}
+
+// Sleep waits for dur to elapse on the derived clock.
+func (d *DerivedTime) Sleep(dur time.Duration) {
+	t := d.NewTimer(dur)
+	defer t.Stop()
+	<-t.Chan()
+}
+
+// NewTimerAt creates new oneshot timer that fires when the derived clock reaches t.
+func (d *DerivedTime) NewTimerAt(t time.Time) Timer {
+	return d.schedule(&MockTimer{at: d.parentTime(t), oneshot: true})
+}
+
+// AfterFuncAt calls f when the derived clock reaches t.
+func (d *DerivedTime) AfterFuncAt(t time.Time, f func()) Timer {
+	return d.schedule(&MockTimer{at: d.parentTime(t), cb: f, oneshot: true})
+}
+
+// SleepUntil waits for the derived clock to reach t.
+func (d *DerivedTime) SleepUntil(t time.Time) {
+	timer := d.NewTimerAt(t)
+	defer timer.Stop()
+	<-timer.Chan()
+}
+
+// WithCancel works as context.WithCancel. Deadline of the context is reported on the derived clock.
+func (d *DerivedTime) WithCancel(ctx context.Context) (context.Context, context.CancelFunc) {
+	c := newMockContext(d.mock, ctx, time.Time{}, false, nil)
+	c.derived = d
+	return c, func() { c.cancel(context.Canceled, nil) }
+}
+
+// WithCancelCause works as context.WithCancelCause. Deadline of the context is reported on the derived clock.
+func (d *DerivedTime) WithCancelCause(ctx context.Context) (context.Context, context.CancelCauseFunc) {
+	c := newMockContext(d.mock, ctx, time.Time{}, false, nil)
+	c.derived = d
+	return c, func(cause error) { c.cancel(context.Canceled, cause) }
+}
+
+// WithDeadline works as context.WithDeadline. deadline is a time on the derived clock.
+func (d *DerivedTime) WithDeadline(ctx context.Context, deadline time.Time) (context.Context, context.CancelFunc) {
+	return d.WithDeadlineCause(ctx, deadline, nil)
+}
+
+// WithDeadlineCause works as context.WithDeadlineCause. deadline is a time on the derived clock.
+func (d *DerivedTime) WithDeadlineCause(ctx context.Context, deadline time.Time, cause error) (context.Context, context.CancelFunc) {
+	c := newMockContext(d.mock, ctx, d.parentTime(deadline), true, cause)
+	c.derived = d
+	c.localDeadline = deadline
+	return c, func() { c.cancel(context.Canceled, nil) }
+}
+
+// WithTimeout works as context.WithTimeout. dur is measured by the derived clock.
+func (d *DerivedTime) WithTimeout(ctx context.Context, dur time.Duration) (context.Context, context.CancelFunc) {
+	return d.WithDeadlineCause(ctx, d.Now().Add(dur), nil)
+}
+
+// WithTimeoutCause works as context.WithTimeoutCause. dur is measured by the derived clock.
+func (d *DerivedTime) WithTimeoutCause(ctx context.Context, dur time.Duration, cause error) (context.Context, context.CancelFunc) {
+	return d.WithDeadlineCause(ctx, d.Now().Add(dur), cause)
+}
+
+// ContextAfterFunc works as MockTime.ContextAfterFunc of the parent.
+func (d *DerivedTime) ContextAfterFunc(ctx context.Context, f func()) (stop func() bool) {
+	return d.mock.ContextAfterFunc(ctx, f)
+}
+
+var _ Time = &DerivedTime{}
+
+// derivedTimer converts durations of Reset to the parent's.
+type derivedTimer struct {
+	p *DerivedTime
+	t *MockTimer
+}
+
+func (t *derivedTimer) Reset(d time.Duration) bool {
+	return t.t.Reset(t.p.parentDuration(d))
+}
+
+func (t *derivedTimer) Stop() bool {
+	t.p.remove(t.t)
+	return t.t.Stop()
+}
+
+func (t *derivedTimer) Chan() <-chan time.Time {
+	return t.t.Chan()
+}
+
+var _ Timer = &derivedTimer{}
@@ -0,0 +1,142 @@
+package itime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDerivedTime_Now(t *testing.T) {
+	start := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	mock := NewMockWith(start)
+	defer mock.Close()
+
+	fast := mock.Derive(time.Second, 1000)
+	slow := mock.Derive(-time.Second, -1000)
+	assert.Equal(t, start.Add(time.Second), fast.Now())
+	assert.Equal(t, start.Add(-time.Second), slow.Now())
+
+	mock.Advance(1000*time.Second, true)
+	// 1000ppm of 1000s is 1s
+	assert.Equal(t, start.Add(1002*time.Second), fast.Now())
+	assert.Equal(t, start.Add(998*time.Second), slow.Now())
+}
+
+func TestDerivedTime_NewTimer(t *testing.T) {
+	start := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	mock := NewMockWith(start)
+	defer mock.Close()
+
+	// The clock runs twice as fast as the parent
+	node := mock.Derive(time.Hour, 1e6)
+	defer node.Close()
+	timer := node.NewTimer(10 * time.Second)
+
+	mock.Advance(4*time.Second, true)
+	select {
+	case <-timer.Chan():
+		t.Fatal("timer should not fire yet")
+	default:
+	}
+	mock.Advance(time.Second, true)
+	// the channel receives the derived clock's time
+	assert.Equal(t, start.Add(time.Hour+10*time.Second), <-timer.Chan())
+}
+
+func TestDerivedTime_ReleaseFiredTimers(t *testing.T) {
+	mock := NewMock()
+	defer mock.Close()
+	node := mock.Derive(0, 0)
+	defer node.Close()
+
+	timer := node.NewTimer(time.Second)
+	called := make(chan struct{})
+	node.AfterFunc(time.Second, func() {
+		close(called)
+	})
+	ticker := node.NewTicker(time.Second)
+	defer ticker.Stop()
+	assert.Equal(t, 3, len(node.timers))
+
+	mock.Advance(time.Second, true)
+	<-timer.Chan()
+	<-called
+	// only the ticker is still pending
+	node.lock.Lock()
+	defer node.lock.Unlock()
+	assert.Equal(t, 1, len(node.timers))
+}
+
+func TestDerivedTime_NewTicker(t *testing.T) {
+	start := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	mock := NewMockWith(start)
+	defer mock.Close()
+
+	// The clock runs at half speed of the parent
+	node := mock.Derive(0, -5e5)
+	defer node.Close()
+	ticker := node.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for i := 1; i <= 3; i++ {
+		mock.Advance(2*time.Second, true)
+		assert.Equal(t, start.Add(time.Duration(i)*time.Second), <-ticker.Chan())
+	}
+}
+
+func TestDerivedTime_WithTimeout(t *testing.T) {
+	mock := NewMock()
+	defer mock.Close()
+
+	leader := mock.Derive(0, 1e5)
+	follower := mock.Derive(0, -1e5)
+
+	// a lease of 10s is judged differently by each node
+	lctx, lcancel := leader.WithTimeout(context.Background(), 10*time.Second)
+	defer lcancel()
+	fctx, fcancel := follower.WithTimeout(context.Background(), 10*time.Second)
+	defer fcancel()
+
+	deadline, ok := lctx.Deadline()
+	assert.True(t, ok)
+	assert.Equal(t, leader.Now().Add(10*time.Second), deadline)
+
+	mock.Advance(10*time.Second, true)
+	assert.Equal(t, context.DeadlineExceeded, lctx.Err())
+	assert.Nil(t, fctx.Err())
+
+	mock.Advance(2*time.Second, true)
+	assert.Equal(t, context.DeadlineExceeded, fctx.Err())
+}
+
+type derivedKey struct{}
+
+func TestDerivedTime_WrappedContext(t *testing.T) {
+	mock := NewMock()
+	defer mock.Close()
+	skewed := mock.Derive(time.Hour, 0)
+
+	derived, cancel := skewed.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	wrapped := context.WithValue(derived, derivedKey{}, "value")
+
+	// the child on the parent's timeline inherits the deadline converted to it
+	child, cancel := mock.WithTimeout(wrapped, 10*time.Minute)
+	defer cancel()
+	deadline, ok := child.Deadline()
+	assert.True(t, ok)
+	assert.Equal(t, mock.Now().Add(time.Minute), deadline)
+
+	// contexts of the derived clock keep its view
+	local, cancel := skewed.WithCancel(wrapped)
+	defer cancel()
+	deadline, ok = local.Deadline()
+	assert.True(t, ok)
+	assert.Equal(t, skewed.Now().Add(time.Minute), deadline)
+
+	mock.Advance(time.Minute, true)
+	assert.Equal(t, context.DeadlineExceeded, child.Err())
+	assert.Equal(t, "value", child.Value(derivedKey{}))
+}
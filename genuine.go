@@ -8,55 +8,112 @@ import (
 )
 
 // GenuineTime is an implementation of real time for Time interface
+//
+// Create it by New. The zero value works as a plain clock: Close doesn't stop its timers,
+// and it can't be observed.
 type GenuineTime struct {
-	timers  []*GenuineTimer
-	tickers []*GenuineTicker
+	// state is kept behind a pointer because Now and Sleep have value receivers.
+	// It is set by New and never changes, so copies share it.
+	state *genuineState
+}
+
+// genuineState is timers and observers of GenuineTime. Its methods accept nil, the state of the zero value.
+type genuineState struct {
+	lock      sync.Mutex
+	timers    []*GenuineTimer
+	tickers   []*GenuineTicker
+	timerID   int64
+	contextID int64
+
+	obs observers
 }
 
 // Close methods stops all internal timer
 func (t *GenuineTime) Close() {
-	for _, timer := range t.timers {
+	s := t.state
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, timer := range s.timers {
 		timer.internalStop()
 	}
-	for _, ticker := range t.tickers {
+	for _, ticker := range s.tickers {
 		ticker.internalStop()
 	}
-	t.timers = nil
-	t.tickers = nil
+	s.timers = nil
+	s.tickers = nil
+}
+
+// AddObserver registers an observer of timer lifecycle events. Call remove to unregister it.
+//
+// It panics if t is not created by New.
+func (t *GenuineTime) AddObserver(o Observer) (remove func()) {
+	if t.state == nil {
+		panic("itime: AddObserver of GenuineTime not created by New")
+	}
+	return t.state.obs.add(o)
+}
+
+// SetLabels sets labels attached to events notified to observers.
+//
+// It panics if t is not created by New.
+func (t *GenuineTime) SetLabels(labels map[string]string) {
+	if t.state == nil {
+		panic("itime: SetLabels of GenuineTime not created by New")
+	}
+	t.state.obs.setLabels(labels)
 }
 
 // Now returns wall clock time
-func (GenuineTime) Now() time.Time {
-	return time.Now()
+func (t GenuineTime) Now() time.Time {
+	now := time.Now()
+	t.state.emit(EventNow, 0, now, 0)
+	return now
 }
 
 // NewTimer creates new oneshot timer
 func (t *GenuineTime) NewTimer(d time.Duration) Timer {
+	s := t.state
 	r := &GenuineTimer{
-		p: t,
-		t: time.NewTimer(d),
-		w: make(chan struct{}),
-		c: make(chan time.Time),
+		p: s,
+		c: make(chan time.Time, 1),
 	}
-	t.timers = append(t.timers, r)
-	go func() {
-		select {
-		case now := <-r.t.C:
-			r.Stop()
-			r.c <- now
-		case <-r.w:
-		}
-	}()
+	s.track(r, func() {
+		r.t = time.AfterFunc(d, func() {
+			s.removeTimer(r)
+			now := time.Now()
+			s.emit(EventFire, r.id, now, 0)
+			select {
+			case r.c <- now:
+			default:
+			}
+		})
+	})
+	s.emit(EventTimer, r.id, time.Now(), d)
 	return r
 }
 
 // NewTicker creates interval timer
 func (t *GenuineTime) NewTicker(d time.Duration) Ticker {
+	s := t.state
 	r := &GenuineTicker{
-		p: t,
-		t: time.NewTicker(d),
+		p:    s,
+		t:    time.NewTicker(d),
+		c:    make(chan time.Time, 1),
+		stop: make(chan struct{}),
 	}
-	t.tickers = append(t.tickers, r)
+	if s != nil {
+		s.lock.Lock()
+		s.timerID++
+		r.id = s.timerID
+		s.tickers = append(s.tickers, r)
+		s.lock.Unlock()
+	}
+	// ticks are forwarded so that observers added later see them too
+	go r.forward()
+	s.emit(EventTicker, r.id, time.Now(), d)
 	return r
 }
 
@@ -64,25 +121,163 @@ func (t *GenuineTime) NewTicker(d time.Duration) Ticker {
 //
 // Resulting timer is for stopping timer.
 func (t *GenuineTime) AfterFunc(d time.Duration, f func()) Timer {
+	s := t.state
 	r := &GenuineTimer{
-		p: t,
-		t: time.NewTimer(d),
-		w: make(chan struct{}),
-		c: make(chan time.Time),
+		p: s,
+		c: make(chan time.Time, 1),
 	}
-	t.timers = append(t.timers, r)
-	go func() {
-		select {
-		case now := <-r.t.C:
-			r.Stop()
+	s.track(r, func() {
+		r.t = time.AfterFunc(d, func() {
+			s.removeTimer(r)
+			s.emit(EventFire, r.id, time.Now(), 0)
 			f()
-			r.c <- now
-		case <-r.w:
+		})
+	})
+	s.emit(EventAfterFunc, r.id, time.Now(), d)
+	return r
+}
+
+// wallClockCheckInterval is the longest real duration that timers waiting for a wall clock
+// instant sleep without checking the wall clock. It bounds the error when the wall clock jumps.
+const wallClockCheckInterval = time.Second
+
+// untilWall returns the duration until t measured by wall clock (not monotonic clock).
+func untilWall(t time.Time) time.Duration {
+	return t.Round(0).Sub(time.Now().Round(0))
+}
+
+// wallWait returns the duration to wait for d on wall clock before checking it again.
+func wallWait(d time.Duration) time.Duration {
+	if d > wallClockCheckInterval {
+		return wallClockCheckInterval
+	}
+	return d
+}
+
+// NewTimerAt creates new oneshot timer that fires when wall clock reaches at.
+//
+// Unlike NewTimer, it follows changes of wall clock (e.g. by NTP or manual setting).
+func (t *GenuineTime) NewTimerAt(at time.Time) Timer {
+	s := t.state
+	r := &GenuineTimer{
+		p: s,
+		c: make(chan time.Time, 1),
+	}
+	return s.startAt(r, at, func() {
+		now := time.Now()
+		s.emit(EventFire, r.id, now, 0)
+		select {
+		case r.c <- now:
+		default:
 		}
-	}()
+	}, EventTimer)
+}
+
+// AfterFuncAt calls f in its own goroutine when wall clock reaches at.
+func (t *GenuineTime) AfterFuncAt(at time.Time, f func()) Timer {
+	s := t.state
+	r := &GenuineTimer{
+		p: s,
+		c: make(chan time.Time, 1),
+	}
+	return s.startAt(r, at, func() {
+		s.emit(EventFire, r.id, time.Now(), 0)
+		f()
+	}, EventAfterFunc)
+}
+
+func (s *genuineState) startAt(r *GenuineTimer, at time.Time, fire func(), kind string) *GenuineTimer {
+	d := untilWall(at)
+	r.at = at
+	r.pending = true
+	s.track(r, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.t = time.AfterFunc(wallWait(d), s.wallTimer(r, fire))
+	})
+	s.emit(kind, r.id, time.Now(), d)
 	return r
 }
 
+// wallTimer returns the callback of the timer for startAt. It waits again until wall clock reaches r.at.
+func (s *genuineState) wallTimer(r *GenuineTimer, fire func()) func() {
+	return func() {
+		r.mu.Lock()
+		if !r.at.IsZero() {
+			if !r.pending {
+				r.mu.Unlock()
+				return
+			}
+			if d := untilWall(r.at); d > 0 {
+				// woke up early or wall clock is rewound
+				r.t.Reset(wallWait(d))
+				r.mu.Unlock()
+				return
+			}
+			r.pending = false
+		}
+		r.mu.Unlock()
+		s.removeTimer(r)
+		fire()
+	}
+}
+
+// track assigns an ID to r and registers it to be stopped by Close. start arms r.
+func (s *genuineState) track(r *GenuineTimer, start func()) {
+	if s == nil {
+		start()
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.timerID++
+	r.id = s.timerID
+	// the callback of r removes it under s.lock, so it can't run before r is appended
+	start()
+	s.timers = append(s.timers, r)
+}
+
+// emit notifies the event to observers.
+func (s *genuineState) emit(kind string, id int64, virtual time.Time, d time.Duration) {
+	if s != nil {
+		s.obs.emit(kind, id, virtual, d)
+	}
+}
+
+// active reports whether any observer is registered.
+func (s *genuineState) active() bool {
+	return s != nil && s.obs.active()
+}
+
+func (s *genuineState) addTimer(r *GenuineTimer) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, timer := range s.timers {
+		if timer == r {
+			return
+		}
+	}
+	s.timers = append(s.timers, r)
+}
+
+func (s *genuineState) removeTimer(r *GenuineTimer) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	timers := make([]*GenuineTimer, 0, len(s.timers))
+	for _, timer := range s.timers {
+		if timer != r {
+			timers = append(timers, timer)
+		}
+	}
+	s.timers = timers
+}
+
 // After is a shorthand of creating Timer instance
 func (t *GenuineTime) After(d time.Duration) <-chan time.Time {
 	r := t.NewTimer(d)
@@ -96,72 +291,163 @@ func (t *GenuineTime) Tick(d time.Duration) <-chan time.Time {
 }
 
 // Sleep waits for the duration to elapse
-func (GenuineTime) Sleep(d time.Duration) {
+func (t GenuineTime) Sleep(d time.Duration) {
+	s := t.state
+	var id int64
+	if s.active() {
+		id = s.newID()
+		s.emit(EventSleep, id, time.Now(), d)
+	}
 	time.Sleep(d)
 	runtime.Gosched()
+	if id != 0 {
+		s.emit(EventSleepEnd, id, time.Now(), 0)
+	}
+}
+
+// SleepUntil waits for wall clock to reach at
+func (t *GenuineTime) SleepUntil(at time.Time) {
+	s := t.state
+	d := untilWall(at)
+	var id int64
+	if s.active() {
+		id = s.newID()
+		s.emit(EventSleep, id, time.Now(), d)
+	}
+	for d > 0 {
+		time.Sleep(wallWait(d))
+		d = untilWall(at)
+	}
+	runtime.Gosched()
+	if id != 0 {
+		s.emit(EventSleepEnd, id, time.Now(), 0)
+	}
 }
 
+func (s *genuineState) newID() int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.timerID++
+	return s.timerID
+}
+
+// observeContext notifies creation and deadline expiry of ctx to observers.
+// EventDeadline is notified only for contexts with a deadline, even if it has already passed.
+func (s *genuineState) observeContext(ctx context.Context, d time.Duration, hasDeadline bool) context.Context {
+	if !s.active() {
+		return ctx
+	}
+	s.lock.Lock()
+	s.contextID++
+	id := s.contextID
+	s.lock.Unlock()
+	s.obs.emit(EventContext, id, time.Now(), d)
+	if hasDeadline {
+		context.AfterFunc(ctx, func() {
+			if ctx.Err() == context.DeadlineExceeded {
+				s.obs.emit(EventDeadline, id, time.Now(), 0)
+			}
+		})
+	}
+	return ctx
+}
+
+// WithCancel is the same as context.WithCancel
+func (t *GenuineTime) WithCancel(ctx context.Context) (context.Context, context.CancelFunc) {
+	s := t.state
+	c, cancel := context.WithCancel(ctx)
+	return s.observeContext(c, 0, false), cancel
+}
+
+// WithCancelCause is the same as context.WithCancelCause
+func (t *GenuineTime) WithCancelCause(ctx context.Context) (context.Context, context.CancelCauseFunc) {
+	s := t.state
+	c, cancel := context.WithCancelCause(ctx)
+	return s.observeContext(c, 0, false), cancel
+}
+
+// WithDeadline is the same as context.WithDeadline
 func (t *GenuineTime) WithDeadline(ctx context.Context, d time.Time) (context.Context, context.CancelFunc) {
-	return context.WithDeadline(ctx, d)
+	return t.WithDeadlineCause(ctx, d, nil)
 }
 
+// WithDeadlineCause is the same as context.WithDeadlineCause
+func (t *GenuineTime) WithDeadlineCause(ctx context.Context, d time.Time, cause error) (context.Context, context.CancelFunc) {
+	s := t.state
+	c, cancel := context.WithDeadlineCause(ctx, d, cause)
+	return s.observeContext(c, time.Until(d), true), cancel
+}
+
+// WithTimeout is the same as context.WithTimeout
 func (t *GenuineTime) WithTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
-	return context.WithTimeout(ctx, d)
+	return t.WithDeadlineCause(ctx, time.Now().Add(d), nil)
+}
+
+// WithTimeoutCause is the same as context.WithTimeoutCause
+func (t *GenuineTime) WithTimeoutCause(ctx context.Context, d time.Duration, cause error) (context.Context, context.CancelFunc) {
+	return t.WithDeadlineCause(ctx, time.Now().Add(d), cause)
+}
+
+// ContextAfterFunc is the same as context.AfterFunc
+func (t *GenuineTime) ContextAfterFunc(ctx context.Context, f func()) (stop func() bool) {
+	return context.AfterFunc(ctx, f)
 }
 
 var _ Time = &GenuineTime{}
 
 // New returns GenuineTime instance
 func New() Time {
-	return &GenuineTime{}
+	return &GenuineTime{state: &genuineState{}}
 }
 
 // GenuineTimer is an actual oneshot timer implementation of Timer interface
+//
+// It is built on time.AfterFunc, so no goroutine is left waiting for it. Like time.Timer,
+// its channel has one buffer, and timers created by AfterFunc never send to it.
 type GenuineTimer struct {
-	p *GenuineTime
-	t *time.Timer
-	w chan struct{}
-	c chan time.Time
-	lock sync.Mutex
+	p  *genuineState
+	id int64
+	t  *time.Timer
+	c  chan time.Time
+
+	// at and pending are used by timers created by NewTimerAt and AfterFuncAt
+	mu      sync.Mutex
+	at      time.Time
+	pending bool
 }
 
 // Reset changes timer duration
 func (t *GenuineTimer) Reset(d time.Duration) bool {
-	return t.t.Reset(d)
+	t.p.addTimer(t)
+	t.p.emit(EventReset, t.id, time.Now(), d)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	active := t.pending
+	t.at = time.Time{}
+	t.pending = false
+	return t.t.Reset(d) || active
 }
 
 func (t *GenuineTimer) internalStop() bool {
-	t.lock.Lock()
-	defer t.lock.Unlock()
-
-	r := t.t.Stop()
-	w := t.w
-	t.w = nil
-	if w != nil {
-		close(w)
-	}
-	return r
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	active := t.pending
+	t.pending = false
+	return t.t.Stop() || active
 }
 
 // Stop stops timer
 func (t *GenuineTimer) Stop() bool {
 	ok := t.internalStop()
-	if ok == true {
-		timers := make([]*GenuineTimer, 0, len(t.p.timers)-1)
-		for _, timer := range t.p.timers {
-			if timer != t {
-				timers = append(timers, timer)
-			}
-		}
-		t.p.timers = timers
+	t.p.removeTimer(t)
+	if ok {
+		t.p.emit(EventStop, t.id, time.Now(), 0)
 	}
 	return ok
 }
 
 // Chan returns channel that sends current time
-func (t GenuineTimer) Chan() <-chan time.Time {
-	t.lock.Lock()
-	defer t.lock.Unlock()
+func (t *GenuineTimer) Chan() <-chan time.Time {
 	return t.c
 }
 
@@ -169,30 +455,64 @@ var _ Timer = &GenuineTimer{}
 
 // GenuineTicker is an actual interval timer implementation of Timer interface
 type GenuineTicker struct {
-	p *GenuineTime
-	t *time.Ticker
+	p  *genuineState
+	id int64
+	t  *time.Ticker
+
+	// c and stop are used to forward ticks to observers
+	c    chan time.Time
+	stop chan struct{}
+	once sync.Once
+}
+
+func (t *GenuineTicker) forward() {
+	for {
+		select {
+		case now := <-t.t.C:
+			t.p.emit(EventFire, t.id, now, 0)
+			select {
+			case t.c <- now:
+			default:
+			}
+		case <-t.stop:
+			return
+		}
+	}
 }
 
 func (t *GenuineTicker) internalStop() {
 	t.t.Stop()
+	t.once.Do(func() {
+		close(t.stop)
+	})
 }
 
 // Stop stops timer. But it doesn't close channel.
 func (t *GenuineTicker) Stop() bool {
 	t.internalStop()
-	tickers := make([]*GenuineTicker, 0, len(t.p.tickers)-1)
-	for _, ticker := range t.p.tickers {
-		if ticker != t {
-			tickers = append(tickers, ticker)
-		}
-	}
-	t.p.tickers = tickers
+	t.p.emit(EventStop, t.id, time.Now(), 0)
+	t.p.removeTicker(t)
 	return true
 }
 
 // Chan returns channel that sends current time
-func (t GenuineTicker) Chan() <-chan time.Time {
-	return t.t.C
+func (t *GenuineTicker) Chan() <-chan time.Time {
+	return t.c
+}
+
+func (s *genuineState) removeTicker(r *GenuineTicker) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	tickers := make([]*GenuineTicker, 0, len(s.tickers))
+	for _, ticker := range s.tickers {
+		if ticker != r {
+			tickers = append(tickers, ticker)
+		}
+	}
+	s.tickers = tickers
 }
 
 var _ Ticker = &GenuineTicker{}
@@ -2,6 +2,8 @@ package itime
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -218,3 +220,146 @@ func TestGenuineTime_AfterFunc2(t *testing.T) {
 	t.Logf("count: %d", finalCount)
 	assert.True(t, finalCount == 0)
 }
+
+func TestGenuineTime_AfterFunc_Chan(t *testing.T) {
+	genuineTime := New().(*GenuineTime)
+	defer genuineTime.Close()
+
+	called := make(chan struct{})
+	timer := genuineTime.AfterFunc(time.Millisecond, func() {
+		close(called)
+	})
+	<-called
+	// Like time.AfterFunc, nothing is sent to the channel and the timer is released
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case <-timer.Chan():
+		t.Error("AfterFunc timer sent a value to its channel")
+	default:
+	}
+	assert.False(t, timer.Stop())
+	genuineTime.state.lock.Lock()
+	defer genuineTime.state.lock.Unlock()
+	assert.Equal(t, 0, len(genuineTime.state.timers))
+}
+
+func TestGenuineTime_NewTimer_Unreceived(t *testing.T) {
+	genuineTime := New().(*GenuineTime)
+	defer genuineTime.Close()
+
+	// the value is kept in the buffer until somebody receives it
+	timer := genuineTime.NewTimer(time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	assert.False(t, timer.Stop())
+	select {
+	case <-timer.Chan():
+	default:
+		t.Error("the value of the fired timer is lost")
+	}
+}
+
+func TestGenuineTime_WithTimeoutCause(t *testing.T) {
+	genuineTime := New()
+	myErr := errors.New("my error")
+
+	ctx, cancel := genuineTime.WithTimeoutCause(context.Background(), 2*time.Millisecond, myErr)
+	defer cancel()
+
+	var called int64
+	genuineTime.ContextAfterFunc(ctx, func() {
+		atomic.AddInt64(&called, 1)
+	})
+	<-ctx.Done()
+	genuineTime.Sleep(5 * time.Millisecond)
+
+	assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+	assert.Equal(t, myErr, context.Cause(ctx))
+	assert.Equal(t, int64(1), atomic.LoadInt64(&called))
+}
+
+func TestGenuineTime_NewTimerAt(t *testing.T) {
+	genuine := New()
+	defer genuine.Close()
+
+	start := time.Now()
+	timer := genuine.NewTimerAt(start.Add(20 * time.Millisecond))
+	fired := <-timer.Chan()
+	assert.False(t, fired.Before(start.Add(20*time.Millisecond)))
+
+	// instants in the past fire immediately
+	past := genuine.NewTimerAt(start.Add(-time.Hour))
+	<-past.Chan()
+
+	stopped := genuine.NewTimerAt(time.Now().Add(time.Hour))
+	assert.True(t, stopped.Stop())
+	assert.False(t, stopped.Stop())
+}
+
+func TestGenuineTime_SleepUntil(t *testing.T) {
+	genuine := New()
+	defer genuine.Close()
+
+	target := time.Now().Add(20 * time.Millisecond)
+	genuine.SleepUntil(target)
+	assert.False(t, time.Now().Before(target))
+
+	done := make(chan struct{})
+	genuine.AfterFuncAt(time.Now().Add(10*time.Millisecond), func() {
+		close(done)
+	})
+	<-done
+}
+
+func TestGenuineTime_ValueReceivers(t *testing.T) {
+	// Now and Sleep are callable on values, as they have always been
+	var value interface {
+		Now() time.Time
+		Sleep(time.Duration)
+	} = GenuineTime{}
+	start := value.Now()
+	value.Sleep(time.Millisecond)
+	assert.True(t, value.Now().After(start))
+
+	// copies of an observed clock share its observers
+	genuineTime := New().(*GenuineTime)
+	defer genuineTime.Close()
+	var events int32
+	genuineTime.AddObserver(ObserverFunc(func(e ObservedEvent) {
+		if e.Kind == EventNow {
+			atomic.AddInt32(&events, 1)
+		}
+	}))
+	(*genuineTime).Now()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&events))
+}
+
+func TestGenuineTime_ZeroValue(t *testing.T) {
+	// the zero value is usable from several goroutines without New
+	var genuineTime GenuineTime
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			genuineTime.Now()
+			genuineTime.Sleep(time.Microsecond)
+			<-genuineTime.NewTimer(time.Millisecond).Chan()
+			fired := make(chan struct{})
+			genuineTime.AfterFunc(time.Millisecond, func() {
+				close(fired)
+			})
+			<-fired
+			ticker := genuineTime.NewTicker(time.Millisecond)
+			<-ticker.Chan()
+			ticker.Stop()
+			ctx, cancel := genuineTime.WithTimeout(context.Background(), time.Millisecond)
+			<-ctx.Done()
+			cancel()
+		}()
+	}
+	wg.Wait()
+	genuineTime.Close()
+	assert.Panics(t, func() {
+		genuineTime.AddObserver(ObserverFunc(func(ObservedEvent) {}))
+	})
+}
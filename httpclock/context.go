@@ -0,0 +1,36 @@
+// Package httpclock provides HTTP handler middleware and http.RoundTripper wrappers
+// whose timeouts and latencies follow itime.Time.
+//
+// http.Server's ReadTimeout/WriteTimeout and http.Client.Timeout use the real clock.
+// Use these helpers instead to drive end-to-end timeouts over httptest servers with itime.MockTime.
+package httpclock
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/shibukawa/itime"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx that carries t.
+func NewContext(ctx context.Context, t itime.Time) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns Time stored by NewContext. It returns itime.New() if ctx doesn't carry it.
+func FromContext(ctx context.Context) itime.Time {
+	if t, ok := ctx.Value(contextKey{}).(itime.Time); ok {
+		return t
+	}
+	return itime.New()
+}
+
+// WithTime returns a handler that injects t into the context of each request.
+// Handlers get it by FromContext(r.Context()).
+func WithTime(h http.Handler, t itime.Time) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(NewContext(r.Context(), t)))
+	})
+}
@@ -0,0 +1,116 @@
+package httpclock
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/shibukawa/itime"
+)
+
+// TimeoutHandler works like http.TimeoutHandler, but its deadline is on t.
+//
+// It runs h with a context that carries t and expires after dt on t. If h doesn't finish in time,
+// it replies 503 Service Unavailable with msg, and later writes of h return http.ErrHandlerTimeout.
+// If msg is empty, a default message is used.
+func TimeoutHandler(h http.Handler, t itime.Time, dt time.Duration, msg string) http.Handler {
+	if msg == "" {
+		msg = "<html><head><title>Timeout</title></head><body><h1>Timeout</h1></body></html>"
+	}
+	return &timeoutHandler{handler: h, time: t, dt: dt, body: msg}
+}
+
+type timeoutHandler struct {
+	handler http.Handler
+	time    itime.Time
+	dt      time.Duration
+	body    string
+}
+
+func (h *timeoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := h.time.WithTimeout(r.Context(), h.dt)
+	defer cancel()
+	r = r.WithContext(NewContext(ctx, h.time))
+	done := make(chan struct{})
+	panicked := make(chan any, 1)
+	tw := &timeoutWriter{header: make(http.Header)}
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				panicked <- p
+			}
+		}()
+		h.handler.ServeHTTP(tw, r)
+		close(done)
+	}()
+	select {
+	case p := <-panicked:
+		panic(p)
+	case <-done:
+		tw.lock.Lock()
+		defer tw.lock.Unlock()
+		dst := w.Header()
+		for k, v := range tw.header {
+			dst[k] = v
+		}
+		if !tw.wroteHeader {
+			tw.code = http.StatusOK
+		}
+		w.WriteHeader(tw.code)
+		w.Write(tw.buf.Bytes())
+	case <-ctx.Done():
+		tw.lock.Lock()
+		defer tw.lock.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+		if ctx.Err() == context.DeadlineExceeded {
+			io.WriteString(w, h.body)
+			tw.err = http.ErrHandlerTimeout
+		} else {
+			tw.err = ctx.Err()
+		}
+	}
+}
+
+// timeoutWriter buffers the response until the handler finishes.
+type timeoutWriter struct {
+	header http.Header
+
+	lock        sync.Mutex
+	buf         bytes.Buffer
+	err         error
+	wroteHeader bool
+	code        int
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.lock.Lock()
+	defer tw.lock.Unlock()
+	if tw.err != nil {
+		return 0, tw.err
+	}
+	if !tw.wroteHeader {
+		tw.writeHeader(http.StatusOK)
+	}
+	return tw.buf.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.lock.Lock()
+	defer tw.lock.Unlock()
+	if tw.err != nil || tw.wroteHeader {
+		return
+	}
+	tw.writeHeader(code)
+}
+
+func (tw *timeoutWriter) writeHeader(code int) {
+	tw.wroteHeader = true
+	tw.code = code
+}
@@ -0,0 +1,112 @@
+package httpclock
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+// slowHandler replies after d on the Time in the request context.
+func slowHandler(d time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-FromContext(r.Context()).After(d):
+			w.Header().Set("X-Slow", "done")
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, "ok")
+		case <-r.Context().Done():
+			// discarded with http.ErrHandlerTimeout
+			io.WriteString(w, "too late")
+		}
+	})
+}
+
+// armed returns a channel that receives when a timer is created on mock.
+func armed(mock *itime.MockTime) <-chan struct{} {
+	c := make(chan struct{}, 1)
+	mock.AddObserver(itime.ObserverFunc(func(e itime.ObservedEvent) {
+		if e.Kind == itime.EventTimer {
+			select {
+			case c <- struct{}{}:
+			default:
+			}
+		}
+	}))
+	return c
+}
+
+func get(t *testing.T, url string) (int, string, http.Header) {
+	resp, err := http.Get(url)
+	if !assert.NoError(t, err) {
+		return 0, "", nil
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body), resp.Header
+}
+
+func TestTimeoutHandler_InTime(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	server := httptest.NewServer(TimeoutHandler(slowHandler(time.Second), mock, 2*time.Second, ""))
+	defer server.Close()
+	handling := armed(mock)
+
+	err := itime.NewSequence(itime.Option{Time: mock}).
+		Event(func() {
+			<-handling
+		}).
+		Wait(time.Second).
+		Do(func() {
+			code, body, header := get(t, server.URL)
+			assert.Equal(t, http.StatusCreated, code)
+			assert.Equal(t, "ok", body)
+			assert.Equal(t, "done", header.Get("X-Slow"))
+		})
+	assert.NoError(t, err)
+}
+
+func TestTimeoutHandler_Timeout(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	server := httptest.NewServer(TimeoutHandler(slowHandler(time.Minute), mock, 2*time.Second, "timeout"))
+	defer server.Close()
+	handling := armed(mock)
+
+	err := itime.NewSequence(itime.Option{Time: mock}).
+		Event(func() {
+			<-handling
+		}).
+		Wait(2 * time.Second).
+		Do(func() {
+			code, body, header := get(t, server.URL)
+			assert.Equal(t, http.StatusServiceUnavailable, code)
+			assert.Equal(t, "timeout", body)
+			assert.Equal(t, "", header.Get("X-Slow"))
+		})
+	assert.NoError(t, err)
+}
+
+func TestWithTime(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	got := make(chan itime.Time, 1)
+	server := httptest.NewServer(WithTime(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- FromContext(r.Context())
+	}), mock))
+	defer server.Close()
+
+	get(t, server.URL)
+	assert.Equal(t, mock, <-got)
+}
+
+func TestFromContext_Default(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	_, ok := FromContext(req.Context()).(*itime.GenuineTime)
+	assert.True(t, ok)
+}
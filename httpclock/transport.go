@@ -0,0 +1,102 @@
+package httpclock
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/shibukawa/itime"
+)
+
+// ErrTimeout is wrapped by errors of TimeoutTransport when the timeout elapses. It also wraps context.DeadlineExceeded.
+var ErrTimeout = fmt.Errorf("httpclock: request timeout exceeded: %w", context.DeadlineExceeded)
+
+// TimeoutTransport returns a RoundTripper that cancels each request when dt elapses on t,
+// like http.Client.Timeout. The timeout covers reading the response body.
+// If base is nil, http.DefaultTransport is used.
+func TimeoutTransport(base http.RoundTripper, t itime.Time, dt time.Duration) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &timeoutTransport{base: base, time: t, dt: dt}
+}
+
+type timeoutTransport struct {
+	base http.RoundTripper
+	time itime.Time
+	dt   time.Duration
+}
+
+func (tt *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := tt.time.WithTimeoutCause(NewContext(req.Context(), tt.time), tt.dt, ErrTimeout)
+	resp, err := tt.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, tt.wrap(ctx, err)
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, ctx: ctx, cancel: cancel, wrap: tt.wrap}
+	return resp, nil
+}
+
+// wrap replaces err by ErrTimeout if the timeout caused it.
+func (tt *timeoutTransport) wrap(ctx context.Context, err error) error {
+	if context.Cause(ctx) == ErrTimeout {
+		return fmt.Errorf("%w (%v)", ErrTimeout, err)
+	}
+	return err
+}
+
+// cancelBody releases the context of the request when the body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	ctx    context.Context
+	cancel context.CancelFunc
+	wrap   func(ctx context.Context, err error) error
+}
+
+func (b *cancelBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		err = b.wrap(b.ctx, err)
+	}
+	return n, err
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// LatencyTransport returns a RoundTripper that waits latency on t before sending each request.
+// The wait is canceled by the context of the request. If base is nil, http.DefaultTransport is used.
+func LatencyTransport(base http.RoundTripper, t itime.Time, latency time.Duration) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &latencyTransport{base: base, time: t, latency: latency}
+}
+
+type latencyTransport struct {
+	base    http.RoundTripper
+	time    itime.Time
+	latency time.Duration
+}
+
+func (lt *latencyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if lt.latency > 0 {
+		timer := lt.time.NewTimer(lt.latency)
+		select {
+		case <-timer.Chan():
+		case <-req.Context().Done():
+			timer.Stop()
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, context.Cause(req.Context())
+		}
+	}
+	return lt.base.RoundTrip(req)
+}
@@ -0,0 +1,125 @@
+package httpclock
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutTransport(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	release := make(chan struct{})
+	arrived := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			arrived <- struct{}{}
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+	defer close(release)
+	client := &http.Client{Transport: TimeoutTransport(nil, mock, time.Second)}
+
+	resp, err := client.Get(server.URL + "/fast")
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "ok", string(body))
+
+	err = itime.NewSequence(itime.Option{Time: mock}).
+		Event(func() {
+			<-arrived
+		}).
+		Wait(time.Second).
+		Do(func() {
+			_, err := client.Get(server.URL + "/slow")
+			assert.True(t, errors.Is(err, ErrTimeout))
+			assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		})
+	assert.NoError(t, err)
+}
+
+func TestTimeoutTransport_Body(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "head")
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	client := &http.Client{Transport: TimeoutTransport(nil, mock, time.Second)}
+
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	err = itime.NewSequence(itime.Option{Time: mock}).
+		Wait(time.Second).
+		Do(func() {
+			_, err := io.ReadAll(resp.Body)
+			assert.True(t, errors.Is(err, ErrTimeout))
+		})
+	assert.NoError(t, err)
+}
+
+func TestLatencyTransport(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	arrived := make(chan time.Time, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- mock.Now()
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: LatencyTransport(nil, mock, 300*time.Millisecond)}
+	start := mock.Now()
+	sending := armed(mock)
+
+	err := itime.NewSequence(itime.Option{Time: mock}).
+		Event(func() {
+			<-sending
+		}).
+		Wait(300 * time.Millisecond).
+		Do(func() {
+			resp, err := client.Get(server.URL)
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+			}
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, 300*time.Millisecond, (<-arrived).Sub(start))
+}
+
+func TestLatencyTransport_Canceled(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	client := &http.Client{Transport: TimeoutTransport(LatencyTransport(nil, mock, time.Minute), mock, time.Second)}
+	sending := armed(mock)
+
+	err := itime.NewSequence(itime.Option{Time: mock}).
+		Event(func() {
+			<-sending
+		}).
+		Wait(time.Second).
+		Do(func() {
+			_, err := client.Get("http://127.0.0.1:1/")
+			assert.True(t, errors.Is(err, ErrTimeout))
+		})
+	assert.NoError(t, err)
+}
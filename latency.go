@@ -0,0 +1,250 @@
+package itime
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// LatencyDistribution decides how late a timer fires.
+type LatencyDistribution interface {
+	Sample(r *rand.Rand) time.Duration
+}
+
+// LatencyOption is an option of latency injection.
+type LatencyOption struct {
+	Distribution LatencyDistribution
+	Seed         int64
+}
+
+type fixedLatency time.Duration
+
+func (l fixedLatency) Sample(r *rand.Rand) time.Duration {
+	return time.Duration(l)
+}
+
+// FixedLatency delays every wake-up by d.
+func FixedLatency(d time.Duration) LatencyDistribution {
+	return fixedLatency(d)
+}
+
+type uniformLatency struct {
+	min time.Duration
+	max time.Duration
+}
+
+func (l uniformLatency) Sample(r *rand.Rand) time.Duration {
+	if l.max <= l.min {
+		return l.min
+	}
+	return l.min + time.Duration(r.Int63n(int64(l.max-l.min)+1))
+}
+
+// UniformLatency delays wake-ups by durations uniformly distributed in [min, max].
+func UniformLatency(min, max time.Duration) LatencyDistribution {
+	return uniformLatency{min: min, max: max}
+}
+
+type longTailLatency struct {
+	base  time.Duration
+	alpha float64
+	max   time.Duration
+}
+
+func (l longTailLatency) Sample(r *rand.Rand) time.Duration {
+	// Pareto distribution: most samples are close to base, but some are much larger.
+	u := 1 - r.Float64()
+	d := time.Duration(float64(l.base) / math.Pow(u, 1/l.alpha))
+	if d > l.max || d < 0 {
+		return l.max
+	}
+	return d
+}
+
+// LongTailLatency delays wake-ups by durations of Pareto distribution.
+//
+// Most delays are close to base, and smaller alpha makes the tail longer. Delays are capped by max.
+func LongTailLatency(base time.Duration, alpha float64, max time.Duration) LatencyDistribution {
+	if alpha <= 0 {
+		panic("non-positive alpha for LongTailLatency")
+	}
+	return longTailLatency{base: base, alpha: alpha, max: max}
+}
+
+// LatencyTime is a decorator of Time that injects latency into wake-ups of timers,
+// tickers, AfterFunc and Sleep. It is mainly used with GenuineTime.
+// MockTime has SetLatency for the same purpose.
+//
+// Context deadlines are not affected.
+type LatencyTime struct {
+	Time
+	latency LatencyDistribution
+
+	lock    sync.Mutex
+	rand    *rand.Rand
+	tickers map[*latencyTicker]struct{}
+}
+
+// NewLatency returns a Time that wraps base and injects latency.
+func NewLatency(base Time, opt LatencyOption) *LatencyTime {
+	return &LatencyTime{
+		Time:    base,
+		latency: opt.Distribution,
+		rand:    rand.New(rand.NewSource(opt.Seed)),
+		tickers: make(map[*latencyTicker]struct{}),
+	}
+}
+
+// Close stops all tickers and the base Time.
+func (l *LatencyTime) Close() {
+	l.lock.Lock()
+	tickers := l.tickers
+	l.tickers = make(map[*latencyTicker]struct{})
+	l.lock.Unlock()
+	for ticker := range tickers {
+		ticker.Stop()
+	}
+	l.Time.Close()
+}
+
+func (l *LatencyTime) delay() time.Duration {
+	if l.latency == nil {
+		return 0
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	d := l.latency.Sample(l.rand)
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// NewTimer creates new oneshot timer that fires late.
+func (l *LatencyTime) NewTimer(d time.Duration) Timer {
+	return &latencyTimer{p: l, t: l.Time.NewTimer(d + l.delay())}
+}
+
+// NewTicker creates interval timer whose each tick arrives late.
+func (l *LatencyTime) NewTicker(d time.Duration) Ticker {
+	r := &latencyTicker{
+		p:    l,
+		t:    l.Time.NewTicker(d),
+		c:    make(chan time.Time, 1),
+		stop: make(chan struct{}),
+	}
+	l.lock.Lock()
+	l.tickers[r] = struct{}{}
+	l.lock.Unlock()
+	go r.run()
+	return r
+}
+
+// AfterFunc waits for the duration and latency to elapse and then calls f.
+func (l *LatencyTime) AfterFunc(d time.Duration, f func()) Timer {
+	return &latencyTimer{p: l, t: l.Time.AfterFunc(d+l.delay(), f)}
+}
+
+// After is a shorthand of creating Timer instance.
+func (l *LatencyTime) After(d time.Duration) <-chan time.Time {
+	return l.NewTimer(d).Chan()
+}
+
+// Tick is a shorthand of creating Ticker instance.
+func (l *LatencyTime) Tick(d time.Duration) <-chan time.Time {
+	return l.NewTicker(d).Chan()
+}
+
+// Sleep waits for the duration and latency to elapse.
+func (l *LatencyTime) Sleep(d time.Duration) {
+	l.Time.Sleep(d + l.delay())
+}
+
+// NewTimerAt creates new oneshot timer that fires late after t.
+func (l *LatencyTime) NewTimerAt(t time.Time) Timer {
+	return &latencyTimer{p: l, t: l.Time.NewTimerAt(t.Add(l.delay()))}
+}
+
+// AfterFuncAt calls f late after t.
+func (l *LatencyTime) AfterFuncAt(t time.Time, f func()) Timer {
+	return &latencyTimer{p: l, t: l.Time.AfterFuncAt(t.Add(l.delay()), f)}
+}
+
+// SleepUntil waits for t and latency to elapse.
+func (l *LatencyTime) SleepUntil(t time.Time) {
+	l.Time.SleepUntil(t.Add(l.delay()))
+}
+
+var _ Time = &LatencyTime{}
+
+type latencyTimer struct {
+	p *LatencyTime
+	t Timer
+}
+
+func (t *latencyTimer) Reset(d time.Duration) bool {
+	return t.t.Reset(d + t.p.delay())
+}
+
+func (t *latencyTimer) Stop() bool {
+	return t.t.Stop()
+}
+
+func (t *latencyTimer) Chan() <-chan time.Time {
+	return t.t.Chan()
+}
+
+var _ Timer = &latencyTimer{}
+
+type latencyTicker struct {
+	p    *LatencyTime
+	t    Ticker
+	c    chan time.Time
+	stop chan struct{}
+	once sync.Once
+}
+
+// run delays each tick of the underlying ticker.
+// Ticks during delay are dropped by the underlying ticker like slow receivers.
+func (t *latencyTicker) run() {
+	for {
+		select {
+		case <-t.t.Chan():
+		case <-t.stop:
+			return
+		}
+		if d := t.p.delay(); d > 0 {
+			timer := t.p.Time.NewTimer(d)
+			select {
+			case <-timer.Chan():
+			case <-t.stop:
+				timer.Stop()
+				return
+			}
+		}
+		select {
+		case t.c <- t.p.Now():
+		default:
+		}
+	}
+}
+
+func (t *latencyTicker) Stop() bool {
+	stopped := false
+	t.once.Do(func() {
+		close(t.stop)
+		t.t.Stop()
+		stopped = true
+	})
+	t.p.lock.Lock()
+	delete(t.p.tickers, t)
+	t.p.lock.Unlock()
+	return stopped
+}
+
+func (t *latencyTicker) Chan() <-chan time.Time {
+	return t.c
+}
+
+var _ Ticker = &latencyTicker{}
@@ -0,0 +1,101 @@
+package itime
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyDistribution(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	assert.Equal(t, 300*time.Millisecond, FixedLatency(300*time.Millisecond).Sample(r))
+
+	uniform := UniformLatency(10*time.Millisecond, 20*time.Millisecond)
+	longTail := LongTailLatency(time.Millisecond, 1.5, time.Second)
+	for i := 0; i < 1000; i++ {
+		d := uniform.Sample(r)
+		assert.True(t, d >= 10*time.Millisecond && d <= 20*time.Millisecond)
+		d = longTail.Sample(r)
+		assert.True(t, d >= time.Millisecond && d <= time.Second)
+	}
+}
+
+func TestMockTime_SetLatency(t *testing.T) {
+	start := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	mock := NewMockWith(start)
+	defer mock.Close()
+	mock.SetLatency(LatencyOption{Distribution: FixedLatency(300 * time.Millisecond)})
+
+	ticker := mock.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	mock.Advance(time.Second, true)
+	select {
+	case <-ticker.Chan():
+		t.Fatal("ticker should arrive late")
+	default:
+	}
+	mock.Advance(300*time.Millisecond, true)
+	assert.Equal(t, start.Add(1300*time.Millisecond), <-ticker.Chan())
+
+	// latency doesn't accumulate for tickers
+	mock.Advance(time.Second, true)
+	assert.Equal(t, start.Add(2300*time.Millisecond), <-ticker.Chan())
+}
+
+func TestMockTime_SetLatency_Seed(t *testing.T) {
+	fireTimes := func(seed int64) []time.Time {
+		start := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+		mock := NewMockWith(start)
+		defer mock.Close()
+		mock.SetLatency(LatencyOption{
+			Distribution: UniformLatency(0, 500*time.Millisecond),
+			Seed:         seed,
+		})
+		var result []time.Time
+		for i := 0; i < 5; i++ {
+			timer := mock.NewTimer(time.Second)
+			mock.Advance(2*time.Second, true)
+			result = append(result, <-timer.Chan())
+		}
+		return result
+	}
+	assert.Equal(t, fireTimes(42), fireTimes(42))
+	assert.NotEqual(t, fireTimes(42), fireTimes(43))
+}
+
+func TestMockTime_SetLatency_Context(t *testing.T) {
+	mock := NewMock()
+	defer mock.Close()
+	mock.SetLatency(LatencyOption{Distribution: FixedLatency(time.Hour)})
+
+	ctx, cancel := mock.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	// context deadlines are not affected
+	mock.Advance(time.Second, true)
+	assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+}
+
+func TestLatencyTime(t *testing.T) {
+	lt := NewLatency(New(), LatencyOption{Distribution: FixedLatency(5 * time.Millisecond)})
+	defer lt.Close()
+
+	start := time.Now()
+	lt.Sleep(time.Millisecond)
+	assert.True(t, time.Since(start) >= 6*time.Millisecond)
+
+	start = time.Now()
+	<-lt.After(time.Millisecond)
+	assert.True(t, time.Since(start) >= 6*time.Millisecond)
+
+	ticker := lt.NewTicker(2 * time.Millisecond)
+	start = time.Now()
+	<-ticker.Chan()
+	assert.True(t, time.Since(start) >= 7*time.Millisecond)
+	assert.True(t, ticker.Stop())
+	assert.False(t, ticker.Stop())
+}
@@ -0,0 +1,176 @@
+package lease
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shibukawa/itime"
+)
+
+// Liveness is a state of Monitor.
+type Liveness int
+
+const (
+	// Alive means that beats arrive within the interval.
+	Alive Liveness = iota
+	// Suspect means that at least one beat is missed.
+	Suspect
+	// Dead means that beats are missed MissedThreshold times in a row.
+	Dead
+)
+
+func (l Liveness) String() string {
+	switch l {
+	case Alive:
+		return "alive"
+	case Suspect:
+		return "suspect"
+	case Dead:
+		return "dead"
+	}
+	return "unknown"
+}
+
+// MonitorOption is an option for NewMonitor.
+type MonitorOption struct {
+	// Time is a clock to detect missed beats. Default is itime.New().
+	Time itime.Time
+	// Interval is the expected interval of beats. Default is one second.
+	Interval time.Duration
+	// MissedThreshold is the number of missed beats in a row to be Dead. Default is 3.
+	MissedThreshold int
+	// OnChange is called after the liveness changes.
+	OnChange func(from, to Liveness)
+}
+
+// Monitor watches heartbeats. Every Interval without a beat counts as a missed beat.
+//
+// It starts Alive as if a beat arrived when it is created.
+type Monitor struct {
+	time      itime.Time
+	interval  time.Duration
+	threshold int
+	onChange  func(from, to Liveness)
+
+	lock     sync.Mutex
+	liveness Liveness
+	last     time.Time
+	missed   int
+	gen      int
+	timer    itime.Timer
+	closed   bool
+}
+
+// NewMonitor creates Monitor and starts watching.
+func NewMonitor(opt MonitorOption) *Monitor {
+	if opt.Time == nil {
+		opt.Time = itime.New()
+	}
+	if opt.Interval <= 0 {
+		opt.Interval = time.Second
+	}
+	if opt.MissedThreshold <= 0 {
+		opt.MissedThreshold = 3
+	}
+	m := &Monitor{
+		time:      opt.Time,
+		interval:  opt.Interval,
+		threshold: opt.MissedThreshold,
+		onChange:  opt.OnChange,
+		last:      opt.Time.Now(),
+	}
+	m.lock.Lock()
+	m.arm()
+	m.lock.Unlock()
+	return m
+}
+
+// Beat records a heartbeat now. It revives Suspect and Dead monitors.
+func (m *Monitor) Beat() {
+	now := m.time.Now()
+	m.lock.Lock()
+	if m.closed {
+		m.lock.Unlock()
+		return
+	}
+	from := m.liveness
+	m.last = now
+	m.missed = 0
+	m.liveness = Alive
+	m.arm()
+	m.lock.Unlock()
+	m.notify(from, Alive)
+}
+
+// Liveness returns the current liveness.
+func (m *Monitor) Liveness() Liveness {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.liveness
+}
+
+// LastBeat returns the time of the last beat.
+func (m *Monitor) LastBeat() time.Time {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.last
+}
+
+// Missed returns the number of beats missed in a row.
+func (m *Monitor) Missed() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.missed
+}
+
+// Stop stops watching. Liveness doesn't change any more.
+func (m *Monitor) Stop() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.closed = true
+	m.gen++
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+}
+
+// arm sets the timer to the deadline of the next beat. Caller must hold m.lock.
+func (m *Monitor) arm() {
+	m.gen++
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+	gen := m.gen
+	at := m.last.Add(time.Duration(m.missed+1) * m.interval)
+	m.timer = m.time.AfterFuncAt(at, func() {
+		m.miss(gen)
+	})
+}
+
+// miss is called when a beat doesn't arrive in time.
+func (m *Monitor) miss(gen int) {
+	m.lock.Lock()
+	if gen != m.gen {
+		m.lock.Unlock()
+		return
+	}
+	m.timer = nil
+	from := m.liveness
+	m.missed++
+	if m.missed >= m.threshold {
+		m.liveness = Dead
+	} else {
+		m.liveness = Suspect
+		m.arm()
+	}
+	to := m.liveness
+	m.lock.Unlock()
+	m.notify(from, to)
+}
+
+func (m *Monitor) notify(from, to Liveness) {
+	if from != to && m.onChange != nil {
+		m.onChange(from, to)
+	}
+}
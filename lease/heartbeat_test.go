@@ -0,0 +1,65 @@
+package lease
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonitor(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	log := &eventLog{}
+	m := NewMonitor(MonitorOption{
+		Time:            mock,
+		Interval:        time.Second,
+		MissedThreshold: 3,
+		OnChange:        func(from, to Liveness) { log.add(from.String() + "->" + to.String()) },
+	})
+	defer m.Stop()
+
+	mock.Advance(900*time.Millisecond, true)
+	m.Beat()
+	mock.Advance(900*time.Millisecond, true)
+	assert.Equal(t, Alive, m.Liveness())
+	assert.Equal(t, 0, m.Missed())
+
+	mock.Advance(100*time.Millisecond, true)
+	assert.Equal(t, Suspect, m.Liveness())
+	assert.Equal(t, 1, m.Missed())
+	mock.Advance(time.Second, true)
+	assert.Equal(t, Suspect, m.Liveness())
+	assert.Equal(t, 2, m.Missed())
+	mock.Advance(time.Second, true)
+	assert.Equal(t, Dead, m.Liveness())
+	assert.Equal(t, 3, m.Missed())
+
+	// dead monitor stays dead until the next beat
+	mock.Advance(time.Minute, true)
+	assert.Equal(t, 3, m.Missed())
+	m.Beat()
+	assert.Equal(t, Alive, m.Liveness())
+	assert.Equal(t, mock.Now(), m.LastBeat())
+	assert.Equal(t, []string{"alive->suspect", "suspect->dead", "dead->alive"}, log.result())
+}
+
+func TestMonitor_Stop(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	m := NewMonitor(MonitorOption{Time: mock, Interval: time.Second})
+
+	m.Stop()
+	mock.Advance(time.Minute, true)
+	assert.Equal(t, Alive, m.Liveness())
+	m.Beat()
+	assert.Equal(t, 0, m.Missed())
+}
+
+func TestLiveness_String(t *testing.T) {
+	assert.Equal(t, "alive", Alive.String())
+	assert.Equal(t, "suspect", Suspect.String())
+	assert.Equal(t, "dead", Dead.String())
+	assert.Equal(t, "unknown", Liveness(42).String())
+}
@@ -0,0 +1,208 @@
+// Package lease provides leases and heartbeat monitors driven by itime.Time.
+//
+// Expiry and missed beats are fired by timers, so failover can be scripted by advancing itime.MockTime.
+package lease
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/shibukawa/itime"
+)
+
+// State is a state of Lease.
+type State int
+
+const (
+	// Free means that nobody holds the lease.
+	Free State = iota
+	// Held means that the holder owns the lease within its TTL.
+	Held
+	// Grace means that the TTL elapsed. The holder can still renew it, but should not act as the owner,
+	// and others can't acquire it until the grace period ends.
+	Grace
+)
+
+func (s State) String() string {
+	switch s {
+	case Free:
+		return "free"
+	case Held:
+		return "held"
+	case Grace:
+		return "grace"
+	}
+	return "unknown"
+}
+
+var (
+	// ErrHeld is returned when another holder owns the lease.
+	ErrHeld = errors.New("lease: held by another holder")
+	// ErrNotHolder is returned when the caller doesn't own the lease.
+	ErrNotHolder = errors.New("lease: not the holder")
+)
+
+// Option is an option for New.
+type Option struct {
+	// Time is a clock of TTL. Default is itime.New().
+	Time itime.Time
+	// TTL is the time to live of an acquisition or renewal. Default is 10 seconds.
+	TTL time.Duration
+	// Grace is the extra time after TTL before the lease is freed.
+	Grace time.Duration
+	// OnGrace is called when the TTL of holder elapses and Grace is positive.
+	OnGrace func(holder string)
+	// OnExpire is called when the lease of holder is freed by expiry. It is not called by Release.
+	OnExpire func(holder string)
+}
+
+// Lease is an exclusive ownership with TTL.
+type Lease struct {
+	time     itime.Time
+	ttl      time.Duration
+	grace    time.Duration
+	onGrace  func(holder string)
+	onExpire func(holder string)
+
+	lock    sync.Mutex
+	state   State
+	holder  string
+	expires time.Time
+	gen     int
+	timer   itime.Timer
+}
+
+// New creates a free Lease.
+func New(opt Option) *Lease {
+	if opt.Time == nil {
+		opt.Time = itime.New()
+	}
+	if opt.TTL <= 0 {
+		opt.TTL = 10 * time.Second
+	}
+	return &Lease{
+		time:     opt.Time,
+		ttl:      opt.TTL,
+		grace:    opt.Grace,
+		onGrace:  opt.OnGrace,
+		onExpire: opt.OnExpire,
+	}
+}
+
+// Acquire makes holder own the lease for TTL. Acquiring a lease already owned by holder renews it.
+// It returns ErrHeld if another holder owns it, including in its grace period.
+func (l *Lease) Acquire(holder string) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.state != Free && l.holder != holder {
+		return ErrHeld
+	}
+	l.holder = holder
+	l.extend()
+	return nil
+}
+
+// Renew extends the lease of holder by TTL from now. It works in the grace period too.
+func (l *Lease) Renew(holder string) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.state == Free || l.holder != holder {
+		return ErrNotHolder
+	}
+	l.extend()
+	return nil
+}
+
+// Release frees the lease of holder.
+func (l *Lease) Release(holder string) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.state == Free || l.holder != holder {
+		return ErrNotHolder
+	}
+	l.free()
+	return nil
+}
+
+// Holder returns the current holder and state. Holder is empty if the lease is free.
+func (l *Lease) Holder() (string, State) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.holder, l.state
+}
+
+// Expires returns when the TTL of the current holder elapses. It returns false if the lease is free.
+func (l *Lease) Expires() (time.Time, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.state == Free {
+		return time.Time{}, false
+	}
+	return l.expires, true
+}
+
+// Close stops the timer. The lease keeps its state.
+func (l *Lease) Close() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.gen++
+	l.stopTimer()
+}
+
+// extend sets state Held and arms the timer at now + TTL. Caller must hold l.lock.
+func (l *Lease) extend() {
+	l.state = Held
+	l.expires = l.time.Now().Add(l.ttl)
+	l.arm(l.expires)
+}
+
+// free clears the holder. Caller must hold l.lock.
+func (l *Lease) free() {
+	l.state = Free
+	l.holder = ""
+	l.expires = time.Time{}
+	l.gen++
+	l.stopTimer()
+}
+
+// arm replaces the timer with the new one at at. Caller must hold l.lock.
+func (l *Lease) arm(at time.Time) {
+	l.gen++
+	l.stopTimer()
+	gen := l.gen
+	l.timer = l.time.AfterFuncAt(at, func() {
+		l.fire(gen)
+	})
+}
+
+func (l *Lease) stopTimer() {
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+}
+
+// fire moves Held to Grace and Grace to Free.
+func (l *Lease) fire(gen int) {
+	l.lock.Lock()
+	if gen != l.gen {
+		l.lock.Unlock()
+		return
+	}
+	l.timer = nil
+	holder := l.holder
+	var callback func(holder string)
+	if l.state == Held && l.grace > 0 {
+		l.state = Grace
+		l.arm(l.expires.Add(l.grace))
+		callback = l.onGrace
+	} else {
+		l.free()
+		callback = l.onExpire
+	}
+	l.lock.Unlock()
+	if callback != nil {
+		callback(holder)
+	}
+}
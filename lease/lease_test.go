@@ -0,0 +1,153 @@
+package lease
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+type eventLog struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func (l *eventLog) add(event string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *eventLog) result() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.events
+}
+
+func TestLease_AcquireRenewRelease(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	log := &eventLog{}
+	l := New(Option{Time: mock, TTL: 10 * time.Second, OnExpire: func(holder string) { log.add("expire:" + holder) }})
+	defer l.Close()
+
+	assert.NoError(t, l.Acquire("a"))
+	assert.Equal(t, ErrHeld, l.Acquire("b"))
+	assert.Equal(t, ErrNotHolder, l.Renew("b"))
+	expires, ok := l.Expires()
+	assert.True(t, ok)
+	assert.Equal(t, mock.Now().Add(10*time.Second), expires)
+
+	mock.Advance(9*time.Second, true)
+	assert.NoError(t, l.Renew("a"))
+	mock.Advance(9*time.Second, true)
+	holder, state := l.Holder()
+	assert.Equal(t, "a", holder)
+	assert.Equal(t, Held, state)
+
+	assert.Equal(t, ErrNotHolder, l.Release("b"))
+	assert.NoError(t, l.Release("a"))
+	holder, state = l.Holder()
+	assert.Equal(t, "", holder)
+	assert.Equal(t, Free, state)
+	_, ok = l.Expires()
+	assert.False(t, ok)
+
+	// released lease doesn't expire
+	mock.Advance(time.Minute, true)
+	assert.Nil(t, log.result())
+}
+
+func TestLease_Expire(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	log := &eventLog{}
+	l := New(Option{Time: mock, TTL: 10 * time.Second, OnExpire: func(holder string) { log.add("expire:" + holder) }})
+	defer l.Close()
+
+	l.Acquire("a")
+	mock.Advance(10*time.Second-time.Nanosecond, true)
+	_, state := l.Holder()
+	assert.Equal(t, Held, state)
+	mock.Advance(time.Nanosecond, true)
+	_, state = l.Holder()
+	assert.Equal(t, Free, state)
+	assert.Equal(t, []string{"expire:a"}, log.result())
+	assert.Equal(t, ErrNotHolder, l.Renew("a"))
+	assert.NoError(t, l.Acquire("b"))
+}
+
+func TestLease_Grace(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	log := &eventLog{}
+	l := New(Option{
+		Time:     mock,
+		TTL:      10 * time.Second,
+		Grace:    5 * time.Second,
+		OnGrace:  func(holder string) { log.add("grace:" + holder) },
+		OnExpire: func(holder string) { log.add("expire:" + holder) },
+	})
+	defer l.Close()
+
+	l.Acquire("a")
+	mock.Advance(10*time.Second, true)
+	_, state := l.Holder()
+	assert.Equal(t, Grace, state)
+	assert.Equal(t, ErrHeld, l.Acquire("b"))
+
+	// renewal in grace period recovers the lease
+	assert.NoError(t, l.Renew("a"))
+	_, state = l.Holder()
+	assert.Equal(t, Held, state)
+
+	// advancing over both TTL and grace at once fires both
+	mock.Advance(time.Minute, true)
+	_, state = l.Holder()
+	assert.Equal(t, Free, state)
+	assert.Equal(t, []string{"grace:a", "grace:a", "expire:a"}, log.result())
+}
+
+func TestLease_Failover(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	log := &eventLog{}
+	l := New(Option{
+		Time:     mock,
+		TTL:      3 * time.Second,
+		Grace:    time.Second,
+		OnExpire: func(holder string) { log.add("expire:" + holder) },
+	})
+	defer l.Close()
+
+	tryAcquire := func(holder string) func() {
+		return func() {
+			if l.Acquire(holder) == nil {
+				log.add("leader:" + holder)
+			}
+		}
+	}
+	err := itime.NewSequence(itime.Option{Time: mock}).
+		Event(tryAcquire("a")).
+		Wait(2 * time.Second).
+		Event(func() { l.Renew("a") }).
+		Event(tryAcquire("b")).
+		Wait(3 * time.Second). // "a" stops renewing; it is in grace
+		Event(tryAcquire("b")).
+		Wait(time.Second).
+		Event(tryAcquire("b")).
+		Do(func() {})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"leader:a", "expire:a", "leader:b"}, log.result())
+	holder, _ := l.Holder()
+	assert.Equal(t, "b", holder)
+}
+
+func TestState_String(t *testing.T) {
+	assert.Equal(t, "free", Free.String())
+	assert.Equal(t, "held", Held.String())
+	assert.Equal(t, "grace", Grace.String())
+	assert.Equal(t, "unknown", State(42).String())
+}
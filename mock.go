@@ -2,6 +2,7 @@ package itime
 
 import (
 	"context"
+	"math/rand"
 	"runtime"
 	"sort"
 	"sync"
@@ -9,53 +10,138 @@ import (
 )
 
 type MockTime struct {
+	lock    sync.Mutex
 	timers  []*MockTimer
 	current time.Time
+
+	// activity is incremented whenever the clock is used. It is used to detect idle state.
+	activity    uint64
+	autoAdvance *autoAdvancer
+
+	latency LatencyDistribution
+	rand    *rand.Rand
+
+	timerID   int64
+	contextID int64
+	replay    *replayer
+
+	obs observers
+}
+
+func (m *MockTime) Now() time.Time {
+	t, ok := m.replayReading()
+	if !ok {
+		t = m.now()
+	}
+	m.obs.emit(EventNow, 0, t, 0)
+	return t
+}
+
+// AddObserver registers an observer of timer lifecycle events. Call remove to unregister it.
+func (m *MockTime) AddObserver(o Observer) (remove func()) {
+	return m.obs.add(o)
 }
 
-func (m MockTime) Now() time.Time {
+// SetLabels sets labels attached to events notified to observers.
+func (m *MockTime) SetLabels(labels map[string]string) {
+	m.obs.setLabels(labels)
+}
+
+// now returns current time. Unlike Now, it doesn't consume readings of replay.
+func (m *MockTime) now() time.Time {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.activity++
 	return m.current
 }
 
 func (m *MockTime) Close() {
+	m.StopAutoAdvance()
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	for _, timer := range m.timers {
-		timer.internalStop()
+		timer.closed = true
 	}
 	m.timers = nil
 }
 
 func (m *MockTime) NewTimer(d time.Duration) Timer {
-	r := &MockTimer{
-		p:       m,
-		c:       make(chan time.Time),
-		d:       d,
-		next:    m.current.Add(d),
-		oneshot: true,
-	}
-	m.timers = append(m.timers, r)
-	return r
+	return m.schedule(&MockTimer{d: d, oneshot: true})
 }
 
 func (m *MockTime) NewTicker(d time.Duration) Ticker {
-	r := &MockTimer{
-		p:       m,
-		c:       make(chan time.Time),
-		d:       d,
-		next:    m.current.Add(d),
-		oneshot: false,
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return m.schedule(&MockTimer{d: d, oneshot: false})
+}
+
+// schedule registers timer r that expires after r.d from now.
+func (m *MockTime) schedule(r *MockTimer) *MockTimer {
+	m.lock.Lock()
+	m.activity++
+	r.p = m
+	r.c = make(chan time.Time, 1)
+	if !r.exact {
+		m.timerID++
+		r.id = m.timerID
+	}
+	if r.at.IsZero() {
+		r.next = m.current.Add(r.d)
+	} else {
+		r.next = r.at
+		r.d = r.at.Sub(m.current)
 	}
+	r.delay = m.drawLatency(r)
 	m.timers = append(m.timers, r)
+	now := m.current
+	m.lock.Unlock()
+
+	if r.observed() {
+		m.obs.emit(r.kind(), r.id, now, r.d)
+	}
 	return r
 }
 
+// SetLatency injects latency into wake-ups of timers, tickers, AfterFunc and Sleep.
+//
+// Each firing is delayed by a duration drawn from opt.Distribution with a random generator
+// seeded by opt.Seed, so the same scenario reproduces the same delays.
+// Channels receive the delayed time like real timers fired late. Context deadlines are not affected.
+// It affects timers scheduled after the call. Nil Distribution disables injection.
+func (m *MockTime) SetLatency(opt LatencyOption) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.latency = opt.Distribution
+	m.rand = rand.New(rand.NewSource(opt.Seed))
+}
+
+// drawLatency returns the delay for next firing of r.
+//
+// Caller must hold m.lock.
+func (m *MockTime) drawLatency(r *MockTimer) time.Duration {
+	if r.exact {
+		return 0
+	}
+	if m.replay != nil {
+		return m.replay.delay(r)
+	}
+	if m.latency == nil {
+		return 0
+	}
+	d := m.latency.Sample(m.rand)
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
 func (m *MockTime) AfterFunc(d time.Duration, f func()) Timer {
-	t := m.NewTimer(d).(*MockTimer)
-	t.cb = f
-	return t
+	return m.schedule(&MockTimer{d: d, cb: f, oneshot: true})
 }
 
 func (m *MockTime) After(d time.Duration) <-chan time.Time {
-	t := m.NewTimer(d).(*MockTimer)
+	t := m.NewTimer(d)
 	return t.Chan()
 }
 
@@ -65,76 +151,191 @@ func (m *MockTime) Tick(d time.Duration) <-chan time.Time {
 }
 
 func (m *MockTime) Sleep(d time.Duration) {
-	t := m.NewTimer(d)
-	defer t.Stop()
+	m.sleep(&MockTimer{d: d, oneshot: true, silent: true})
+}
+
+// NewTimerAt creates new oneshot timer that fires when current time reaches t.
+//
+// It fires even if Set jumps over t, and is not moved when Set rewinds the clock.
+// Like timers of non-positive durations, t that has already passed doesn't fire immediately,
+// but on the next Advance or Set (or by auto advance).
+func (m *MockTime) NewTimerAt(t time.Time) Timer {
+	return m.schedule(&MockTimer{at: t, oneshot: true})
+}
+
+// AfterFuncAt calls f when current time reaches t. As NewTimerAt, past t is processed by the next Advance or Set.
+func (m *MockTime) AfterFuncAt(t time.Time, f func()) Timer {
+	return m.schedule(&MockTimer{at: t, cb: f, oneshot: true})
+}
+
+// SleepUntil waits for current time to reach t. As NewTimerAt, it returns by the next Advance or Set even if t has passed.
+func (m *MockTime) SleepUntil(t time.Time) {
+	m.sleep(&MockTimer{at: t, oneshot: true, silent: true})
+}
+
+func (m *MockTime) sleep(r *MockTimer) {
+	t := m.schedule(r)
+	m.obs.emit(EventSleep, t.id, m.now(), t.d)
 	<-t.Chan()
+	t.Stop()
+	m.obs.emit(EventSleepEnd, t.id, m.now(), 0)
 }
 
 func (m *MockTime) Advance(d time.Duration, processTimer bool) {
-	newCurrent := m.current.Add(d)
+	newCurrent := m.now().Add(d)
 	m.Set(newCurrent, processTimer)
-	for i := 0; i < len(m.timers)*2; i++ {
+	m.lock.Lock()
+	count := len(m.timers) * 2
+	m.lock.Unlock()
+	for i := 0; i < count; i++ {
 		runtime.Gosched()
 	}
 }
 
 func (m *MockTime) Set(t time.Time, processTimer bool) {
-	if t.Before(m.current) || len(m.timers) == 0 {
+	m.lock.Lock()
+	m.activity++
+	if t.Before(m.current) {
 		m.current = t
+		m.lock.Unlock()
 		return
 	}
+	m.lock.Unlock()
+	m.advanceTo(t, processTimer)
+}
+
+// advanceTo moves current time forward to t and processes timers on the way.
+//
+// Unlike Set, it never moves current time backward. So it is safe to call from several goroutines.
+func (m *MockTime) advanceTo(t time.Time, processTimer bool) {
 	for {
-		timers := make([]*MockTimer, 0, len(m.timers))
-		for _, timer := range m.timers {
-			if timer.next.Before(t) || timer.next.Equal(t) {
-				timers = append(timers, timer)
+		m.lock.Lock()
+		timer := m.nextTimer(t)
+		if timer == nil {
+			if t.After(m.current) {
+				m.current = t
 			}
+			m.lock.Unlock()
+			return
 		}
-		if len(timers) == 0 {
-			break
+		now := timer.due()
+		if now.After(m.current) {
+			m.current = now
 		}
-		sort.Slice(timers, func(i, j int) bool {
-			return timers[i].next.Before(timers[j].next)
-		})
-		timer := timers[0]
-		m.current = timer.next
-		if processTimer {
-			success := false
-			for i := 0; i < 10; i++ {
-				select {
-				case timer.c <- timer.next:
-					success = true
-				default:
-				}
-				runtime.Gosched()
-				if success {
-					break
-				}
-			}
-			if timer.cb != nil {
-				timer.cb()
-			}
+		if m.replay != nil && !timer.exact {
+			m.replay.popFire(timer.id)
 		}
 		if timer.oneshot {
-			timer.Stop()
+			timer.closed = true
+			m.removeTimer(timer)
 		} else {
 			timer.next = timer.next.Add(timer.d)
+			timer.delay = m.drawLatency(timer)
+		}
+		m.lock.Unlock()
+
+		if timer.oneshot && timer.expired != nil {
+			timer.expired()
+		}
+		if processTimer {
+			if timer.observed() {
+				m.obs.emit(EventFire, timer.id, now, 0)
+			}
+			timer.fire(now)
+		}
+	}
+}
+
+// moveTo moves current time forward to t without processing timers.
+//
+// Timers that expire on the way are kept and processed by the next advanceTo call.
+func (m *MockTime) moveTo(t time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if t.After(m.current) {
+		m.current = t
+	}
+}
+
+// nextDeadline returns the time when the earliest timer expires.
+func (m *MockTime) nextDeadline() (time.Time, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var next time.Time
+	found := false
+	for _, timer := range m.timers {
+		if !found || timer.due().Before(next) {
+			next = timer.due()
+			found = true
+		}
+	}
+	return next, found
+}
+
+// nextTimer returns the earliest timer that expires until t.
+//
+// Caller must hold m.lock.
+func (m *MockTime) nextTimer(t time.Time) *MockTimer {
+	timers := make([]*MockTimer, 0, len(m.timers))
+	for _, timer := range m.timers {
+		if !timer.due().After(t) {
+			timers = append(timers, timer)
 		}
 	}
+	if len(timers) == 0 {
+		return nil
+	}
+	sort.SliceStable(timers, func(i, j int) bool {
+		return timers[i].due().Before(timers[j].due())
+	})
+	return timers[0]
+}
 
-	m.current = t
+func (m *MockTime) WithCancel(ctx context.Context) (context.Context, context.CancelFunc) {
+	c := newMockContext(m, ctx, time.Time{}, false, nil)
+	return c, func() { c.cancel(context.Canceled, nil) }
+}
+
+func (m *MockTime) WithCancelCause(ctx context.Context) (context.Context, context.CancelCauseFunc) {
+	c := newMockContext(m, ctx, time.Time{}, false, nil)
+	return c, func(cause error) { c.cancel(context.Canceled, cause) }
 }
 
 func (m *MockTime) WithDeadline(ctx context.Context, d time.Time) (context.Context, context.CancelFunc) {
-	return newMockContext(m, ctx, d)
+	return m.WithDeadlineCause(ctx, d, nil)
+}
+
+func (m *MockTime) WithDeadlineCause(ctx context.Context, d time.Time, cause error) (context.Context, context.CancelFunc) {
+	c := newMockContext(m, ctx, d, true, cause)
+	return c, func() { c.cancel(context.Canceled, nil) }
 }
 
 func (m *MockTime) WithTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
-	return newMockContext(m, ctx, m.Now().Add(d))
+	return m.WithDeadlineCause(ctx, m.now().Add(d), nil)
 }
 
+func (m *MockTime) WithTimeoutCause(ctx context.Context, d time.Duration, cause error) (context.Context, context.CancelFunc) {
+	return m.WithDeadlineCause(ctx, m.now().Add(d), cause)
+}
+
+// ContextAfterFunc arranges to call f in its own goroutine after ctx is done like context.AfterFunc.
+//
+// If ctx is already done, f is started immediately.
+// Otherwise it falls back to context.AfterFunc for contexts not created by this MockTime.
+func (m *MockTime) ContextAfterFunc(ctx context.Context, f func()) (stop func() bool) {
+	if c, ok := ctx.(*mockContext); ok {
+		return c.AfterFunc(func() {
+			go f()
+		})
+	}
+	return context.AfterFunc(ctx, f)
+}
+
+// removeTimer removes t from the timer list.
+//
+// Caller must hold m.lock.
 func (m *MockTime) removeTimer(t *MockTimer) {
-	timers := make([]*MockTimer, 0, len(m.timers)-1)
+	timers := make([]*MockTimer, 0, len(m.timers))
 	for _, timer := range m.timers {
 		if timer != t {
 			timers = append(timers, timer)
@@ -158,43 +359,80 @@ func NewMockWith(t time.Time) *MockTime {
 }
 
 type MockTimer struct {
-	p       *MockTime
-	c       chan time.Time
-	d       time.Duration
-	next    time.Time
-	cb      func()
+	p     *MockTime
+	id    int64
+	c     chan time.Time
+	d     time.Duration
+	next  time.Time
+	at    time.Time
+	delay time.Duration
+	cb    func()
+	conv  func(time.Time) time.Time
+	// expired is called when the oneshot timer fires and leaves the timer list
+	expired func()
 	oneshot bool
 	closed  bool
-	lock    sync.Mutex
+	// exact timers are not affected by latency injection and not observed
+	exact bool
+	// silent timers are not observed. Sleep notifies its own events instead.
+	silent bool
+}
+
+func (m *MockTimer) observed() bool {
+	return !m.exact && !m.silent
+}
+
+func (m *MockTimer) kind() string {
+	switch {
+	case !m.oneshot:
+		return EventTicker
+	case m.cb != nil:
+		return EventAfterFunc
+	default:
+		return EventTimer
+	}
+}
+
+// due returns the time when the timer actually fires.
+func (m *MockTimer) due() time.Time {
+	return m.next.Add(m.delay)
 }
 
 func (m *MockTimer) Reset(d time.Duration) bool {
-	m.lock.Lock()
-	defer m.lock.Unlock()
+	m.p.lock.Lock()
+	m.p.activity++
 	if m.closed {
+		m.p.lock.Unlock()
 		return false
 	}
 	m.d = d
-	m.next = m.p.Now().Add(d)
-	return true
-}
+	m.at = time.Time{}
+	m.next = m.p.current.Add(d)
+	m.delay = m.p.drawLatency(m)
+	now := m.p.current
+	m.p.lock.Unlock()
 
-func (m *MockTimer) internalStop() bool {
-	m.lock.Lock()
-	defer m.lock.Unlock()
-	if m.closed {
-		return false
+	if m.observed() {
+		m.p.obs.emit(EventReset, m.id, now, d)
 	}
-	m.closed = true
 	return true
 }
 
 func (m *MockTimer) Stop() bool {
-	ok := m.internalStop()
-	if !ok {
+	m.p.lock.Lock()
+	m.p.activity++
+	if m.closed {
+		m.p.lock.Unlock()
 		return false
 	}
+	m.closed = true
 	m.p.removeTimer(m)
+	now := m.p.current
+	m.p.lock.Unlock()
+
+	if m.observed() {
+		m.p.obs.emit(EventStop, m.id, now, 0)
+	}
 	return true
 }
 
@@ -202,4 +440,36 @@ func (m *MockTimer) Chan() <-chan time.Time {
 	return m.c
 }
 
+// fire calls callback or sends now to the channel.
+//
+// The channel has one buffer like time.Timer, so a value is not lost even if the receiver
+// is not ready yet. It yields to let the receiver consume it before processing next timer.
+// If the buffer is still full, the value is dropped like time.Ticker does for slow receivers.
+func (m *MockTimer) fire(now time.Time) {
+	if m.cb != nil {
+		// Like time.AfterFunc, the channel is not used.
+		m.cb()
+		return
+	}
+	if m.conv != nil {
+		now = m.conv(now)
+	}
+	for i := 0; i < 10; i++ {
+		success := false
+		select {
+		case m.c <- now:
+			success = true
+		default:
+		}
+		runtime.Gosched()
+		if success {
+			break
+		}
+	}
+	for i := 0; i < 10 && len(m.c) > 0; i++ {
+		runtime.Gosched()
+	}
+	runtime.Gosched()
+}
+
 var _ Timer = &MockTimer{}
@@ -2,6 +2,7 @@ package itime
 
 import (
 	"context"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -108,6 +109,13 @@ func TestMockTime_AfterFunc(t *testing.T) {
 
 	finalCount = int(atomic.LoadInt64(&counter))
 	assert.Equal(t, 1, finalCount)
+
+	// Like time.AfterFunc, nothing is sent to the channel
+	select {
+	case <-timer2.Chan():
+		t.Error("AfterFunc timer sent a value to its channel")
+	default:
+	}
 }
 
 func TestMockTime_MultipleTimer(t *testing.T) {
@@ -151,6 +159,55 @@ func TestMockTime_MultipleTimer(t *testing.T) {
 	mock.Close()
 }
 
+func TestMockTime_NewTicker_NonPositive(t *testing.T) {
+	mock := NewMock()
+	defer mock.Close()
+
+	// same as time.NewTicker
+	assert.Panics(t, func() {
+		mock.NewTicker(0)
+	})
+	assert.Panics(t, func() {
+		mock.NewTicker(-time.Second)
+	})
+}
+
+func TestMockTimer_Buffered(t *testing.T) {
+	now := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	mock := NewMockWith(now)
+	defer mock.Close()
+
+	// like time.Timer, the value is kept until somebody receives it
+	timer := mock.NewTimer(time.Second)
+	mock.Advance(time.Minute, true)
+	assert.Equal(t, now.Add(time.Second), <-timer.Chan())
+	assert.False(t, timer.Stop())
+}
+
+func TestMockTime_Concurrent(t *testing.T) {
+	mock := NewMock()
+	defer mock.Close()
+
+	// run with -race: timers are created, reset and stopped while the clock advances
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				timer := mock.NewTimer(time.Duration(j) * time.Millisecond)
+				mock.Now()
+				timer.Reset(time.Millisecond)
+				timer.Stop()
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		mock.Advance(time.Millisecond, true)
+	}
+	wg.Wait()
+}
+
 func TestMockTime_WithDeadline(t *testing.T) {
 
 }
@@ -177,3 +234,76 @@ func TestMockTimer_Reset(t *testing.T) {
 	// Reset return false after stop
 	assert.False(t, timer.Reset(3*time.Minute))
 }
+
+func TestMockTime_NewTimerAt(t *testing.T) {
+	now := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	mock := NewMockWith(now)
+	defer mock.Close()
+
+	timer := mock.NewTimerAt(now.Add(time.Hour))
+
+	// rewinding the clock doesn't move the instant
+	mock.Set(now.Add(-time.Hour), true)
+	mock.Advance(time.Hour, true)
+	select {
+	case <-timer.Chan():
+		t.Fatal("timer should not fire before the instant")
+	default:
+	}
+
+	// jumping over the instant fires the timer with the instant
+	mock.Set(now.Add(3*time.Hour), true)
+	assert.Equal(t, now.Add(time.Hour), <-timer.Chan())
+}
+
+func TestMockTime_AfterFuncAt(t *testing.T) {
+	now := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	mock := NewMockWith(now)
+	defer mock.Close()
+
+	var firedAt time.Time
+	mock.AfterFuncAt(now.Add(time.Minute), func() {
+		firedAt = mock.now()
+	})
+	mock.Advance(time.Hour, true)
+	assert.Equal(t, now.Add(time.Minute), firedAt)
+}
+
+func TestMockTime_SleepUntil(t *testing.T) {
+	now := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	mock := NewMockWith(now)
+	defer mock.Close()
+
+	sleeping := observed(mock, EventSleep)
+	done := make(chan time.Time)
+	go func() {
+		mock.SleepUntil(now.Add(time.Minute))
+		done <- mock.Now()
+	}()
+	<-sleeping
+	mock.Set(now.Add(2*time.Minute), true)
+	assert.False(t, (<-done).Before(now.Add(time.Minute)))
+}
+
+func TestMockTime_SleepUntil_Past(t *testing.T) {
+	now := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	mock := NewMockWith(now)
+	defer mock.Close()
+
+	// a past instant is processed by the next Advance, like NewTimer(0)
+	sleeping := observed(mock, EventSleep)
+	done := make(chan struct{})
+	go func() {
+		mock.SleepUntil(now.Add(-time.Minute))
+		close(done)
+	}()
+	<-sleeping
+	select {
+	case <-done:
+		t.Fatal("SleepUntil returned before Advance")
+	default:
+	}
+	mock.Advance(0, true)
+	<-done
+	assert.Equal(t, now, mock.Now())
+}
@@ -2,67 +2,283 @@ package itime
 
 import (
 	"context"
-	"runtime"
+	"fmt"
 	"sync"
 	"time"
 )
 
 type mockContext struct {
-	time     *MockTime
-	deadline time.Time
-	ch       chan struct{}
-	err      error
-	parent   context.Context
+	time        *MockTime
+	id          int64
+	parent      context.Context
+	deadline    time.Time
+	hasDeadline bool
+	// localDeadline is the deadline on the clock that created the context
+	// if it has different view from MockTime (e.g. DerivedTime).
+	localDeadline time.Time
+	// derived is the DerivedTime that created the context. It is nil for MockTime.
+	derived *DerivedTime
+
+	lock       sync.Mutex
+	ch         chan struct{}
+	err        error
+	afterFuncs map[*mockAfterFunc]struct{}
+	timer      Timer
+	stopParent func() bool
+
+	// causeCtx keeps the cause of cancellation. context.Cause finds it via Value().
+	causeCtx    context.Context
+	causeCancel context.CancelCauseFunc
 }
 
-func newMockContext(t *MockTime, parent context.Context, d time.Time) (*mockContext, context.CancelFunc) {
-	timer := t.NewTimer(d.Sub(t.Now()))
-	var once sync.Once
+type mockAfterFunc struct {
+	f func()
+}
 
+// newMockContext creates a context that is canceled when the parent is done or
+// when the MockTime reaches d.
+//
+// Like context.WithDeadline, the deadline is ignored if the parent's deadline is earlier than d.
+// In that case, it is canceled by the parent and doesn't create a timer.
+func newMockContext(t *MockTime, parent context.Context, d time.Time, hasDeadline bool, cause error) *mockContext {
+	if parent == nil {
+		panic("cannot create context from nil parent")
+	}
+	if hasDeadline {
+		if current, ok := baseDeadline(parent); ok && current.Before(d) {
+			hasDeadline = false
+		}
+	}
 	c := &mockContext{
-		time:     t,
-		deadline: d,
-		ch:       make(chan struct{}),
-		parent:   parent,
-	}
-	closeChan := func(err error) {
-		once.Do(func() {
-			c.err = err
-		})
+		time:        t,
+		deadline:    d,
+		hasDeadline: hasDeadline,
+		ch:          make(chan struct{}),
+		parent:      parent,
+		afterFuncs:  make(map[*mockAfterFunc]struct{}),
 	}
-	cancel := func() {
-		closeChan(context.Canceled)
+	c.causeCtx, c.causeCancel = context.WithCancelCause(context.Background())
+	t.lock.Lock()
+	t.contextID++
+	c.id = t.contextID
+	now := t.current
+	t.lock.Unlock()
+	var dur time.Duration
+	if hasDeadline {
+		dur = d.Sub(now)
 	}
-	go func() {
-		select {
-		case <-parent.Done():
-			closeChan(parent.Err())
-		case <-timer.Chan():
-			closeChan(context.DeadlineExceeded)
+	t.obs.emit(EventContext, c.id, now, dur)
+
+	c.propagateCancel()
+	if !hasDeadline {
+		return c
+	}
+	expire := func() {
+		c.lock.Lock()
+		done := c.err != nil
+		c.lock.Unlock()
+		if done {
+			return
 		}
+		t.obs.emit(EventDeadline, c.id, t.now(), 0)
+		c.cancel(context.DeadlineExceeded, cause)
+	}
+	if dur <= 0 {
+		expire()
+		return c
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.err == nil {
+		c.timer = t.schedule(&MockTimer{
+			d:       dur,
+			cb:      expire,
+			oneshot: true,
+			exact:   true,
+		})
+	}
+	return c
+}
+
+// propagateCancel arranges for c to be canceled when parent is.
+//
+// Mock parents cancel c synchronously in their cancel. Other parents are watched via context.AfterFunc.
+func (c *mockContext) propagateCancel() {
+	parent := c.parent
+	done := parent.Done()
+	if done == nil {
+		return
+	}
+	select {
+	case <-done:
+		c.cancel(parent.Err(), context.Cause(parent))
+		return
+	default:
+	}
+	f := func() {
+		c.cancel(parent.Err(), context.Cause(parent))
+	}
+	var stop func() bool
+	if p, ok := parent.(*mockContext); ok {
+		stop = p.AfterFunc(f)
+	} else {
+		stop = context.AfterFunc(parent, f)
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.err != nil {
+		stop()
+		return
+	}
+	c.stopParent = stop
+}
+
+// cancel closes the done channel, calls registered callbacks and releases the timer
+// and the registration to the parent.
+//
+// Only the first call is effective. If cause is nil, err is used as cause.
+func (c *mockContext) cancel(err, cause error) {
+	c.lock.Lock()
+	if c.err != nil {
+		c.lock.Unlock()
+		return
+	}
+	if cause == nil {
+		cause = err
+	}
+	c.err = err
+	c.causeCancel(cause)
+	close(c.ch)
+	afterFuncs := c.afterFuncs
+	c.afterFuncs = nil
+	timer := c.timer
+	c.timer = nil
+	stopParent := c.stopParent
+	c.stopParent = nil
+	c.lock.Unlock()
+
+	if timer != nil {
 		timer.Stop()
-	}()
-	runtime.Gosched()
-	return c, cancel
+	}
+	if stopParent != nil {
+		stopParent()
+	}
+	for a := range afterFuncs {
+		a.f()
+	}
+}
+
+// AfterFunc registers f to be called when the context is done.
+//
+// It is also used by the context package to propagate cancellation to child contexts.
+func (c *mockContext) AfterFunc(f func()) (stop func() bool) {
+	a := &mockAfterFunc{f: f}
+	c.lock.Lock()
+	if c.err != nil {
+		c.lock.Unlock()
+		f()
+		return func() bool { return false }
+	}
+	c.afterFuncs[a] = struct{}{}
+	c.lock.Unlock()
+	return func() bool {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		if _, ok := c.afterFuncs[a]; !ok {
+			return false
+		}
+		delete(c.afterFuncs, a)
+		return true
+	}
 }
 
-func (c mockContext) Deadline() (deadline time.Time, ok bool) {
-	return c.deadline, true
+// mockContextKey is the key of Value to find the nearest mock context wrapped by other contexts.
+type mockContextKey struct{}
+
+// deadlineSource returns the mock context whose deadline is the deadline of ctx.
+// It is nil if ctx has no deadline or its deadline is not set by mock contexts.
+func deadlineSource(ctx context.Context) *mockContext {
+	c, ok := ctx.(*mockContext)
+	if !ok {
+		c, _ = ctx.Value(mockContextKey{}).(*mockContext)
+		if c == nil {
+			return nil
+		}
+		// ctx wraps c (e.g. by context.WithValue). Check that it doesn't have its own deadline.
+		d, ok := ctx.Deadline()
+		cd, cok := c.Deadline()
+		if ok != cok || !d.Equal(cd) {
+			return nil
+		}
+	}
+	if !c.hasDeadline {
+		return deadlineSource(c.parent)
+	}
+	return c
 }
 
-func (c mockContext) Done() <-chan struct{} {
+// baseDeadline returns the deadline of ctx on the MockTime's timeline.
+func baseDeadline(ctx context.Context) (time.Time, bool) {
+	if c := deadlineSource(ctx); c != nil {
+		return c.deadline, true
+	}
+	return ctx.Deadline()
+}
+
+func (c *mockContext) Deadline() (deadline time.Time, ok bool) {
+	source := c
+	if !c.hasDeadline {
+		source = deadlineSource(c.parent)
+		if source == nil {
+			return c.parent.Deadline()
+		}
+	}
+	switch {
+	case source.derived == c.derived && !source.localDeadline.IsZero():
+		return source.localDeadline, true
+	case c.derived != nil:
+		// inherited from a clock with another view
+		return c.derived.local(source.deadline), true
+	}
+	return source.deadline, true
+}
+
+func (c *mockContext) Done() <-chan struct{} {
 	return c.ch
 }
 
-func (c mockContext) Err() error {
-	if c.err == nil {
-		return c.parent.Err()
+func (c *mockContext) Err() error {
+	c.lock.Lock()
+	err := c.err
+	c.lock.Unlock()
+	if err != nil {
+		return err
 	}
-	return c.err
+	// Parents other than mockContext notify cancellation asynchronously.
+	// Catch up here so that Err() is consistent with parent's Err().
+	if err := c.parent.Err(); err != nil {
+		c.cancel(err, context.Cause(c.parent))
+		return c.Err()
+	}
+	return nil
 }
 
-func (c mockContext) Value(key interface{}) interface{} {
+func (c *mockContext) Value(key interface{}) interface{} {
+	if key == (mockContextKey{}) {
+		return c
+	}
+	if v := c.causeCtx.Value(key); v != nil {
+		return v
+	}
 	return c.parent.Value(key)
 }
 
+func (c *mockContext) String() string {
+	if c.hasDeadline {
+		deadline, _ := c.Deadline()
+		return fmt.Sprintf("%v.WithDeadline(%v [%v])", c.parent, deadline, c.deadline.Sub(c.time.now()))
+	}
+	return fmt.Sprintf("%v.WithCancel", c.parent)
+}
+
 var _ context.Context = &mockContext{}
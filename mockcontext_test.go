@@ -2,7 +2,11 @@ package itime
 
 import (
 	"context"
+	"errors"
 	"github.com/stretchr/testify/assert"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -72,7 +76,8 @@ func TestMockContext_TimeoutByParent(t *testing.T) {
 	parent, _ := mt.WithTimeout(context.Background(), time.Second)
 	ctx, cancel := mt.WithTimeout(parent, 10*time.Second)
 	deadline, ok := ctx.Deadline()
-	assert.Equal(t, mt.Now().Add(10*time.Second), deadline)
+	// parent's deadline is earlier
+	assert.Equal(t, mt.Now().Add(time.Second), deadline)
 	assert.True(t, ok)
 	assert.Nil(t, ctx.Err())
 
@@ -95,3 +100,223 @@ func TestMockContext_Value(t *testing.T) {
 
 	assert.Equal(t, "value", ctx.Value(key))
 }
+
+func TestMockContext_WithCancel(t *testing.T) {
+	mt := NewMock()
+	ctx, cancel := mt.WithCancel(context.Background())
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+	assert.Nil(t, ctx.Err())
+
+	cancel()
+	<-ctx.Done()
+	assert.Equal(t, context.Canceled, ctx.Err())
+	assert.Equal(t, context.Canceled, context.Cause(ctx))
+}
+
+func TestMockContext_WithCancelCause(t *testing.T) {
+	mt := NewMock()
+	myErr := errors.New("my error")
+	ctx, cancel := mt.WithCancelCause(context.Background())
+	assert.Nil(t, context.Cause(ctx))
+
+	cancel(myErr)
+	<-ctx.Done()
+	assert.Equal(t, context.Canceled, ctx.Err())
+	assert.Equal(t, myErr, context.Cause(ctx))
+}
+
+func TestMockContext_WithTimeoutCause(t *testing.T) {
+	mt := NewMock()
+	myErr := errors.New("my error")
+	// stdlib's cancelCtx in ancestors should not hide the cause
+	parent, pcancel := context.WithCancel(context.Background())
+	defer pcancel()
+	ctx, cancel := mt.WithTimeoutCause(parent, time.Second, myErr)
+	defer cancel()
+
+	mt.Advance(time.Second, true)
+	<-ctx.Done()
+	assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+	assert.Equal(t, myErr, context.Cause(ctx))
+}
+
+func TestMockContext_WithDeadlineCause_Cancel(t *testing.T) {
+	mt := NewMock()
+	myErr := errors.New("my error")
+	ctx, cancel := mt.WithDeadlineCause(context.Background(), mt.Now().Add(time.Second), myErr)
+
+	// cause is used only when the deadline is exceeded
+	cancel()
+	assert.Equal(t, context.Canceled, ctx.Err())
+	assert.Equal(t, context.Canceled, context.Cause(ctx))
+}
+
+func TestMockContext_StdlibChild(t *testing.T) {
+	mt := NewMock()
+	myErr := errors.New("my error")
+	parent, cancel := mt.WithTimeoutCause(context.Background(), time.Second, myErr)
+	defer cancel()
+	ctx, ccancel := context.WithCancel(parent)
+	defer ccancel()
+
+	mt.Advance(time.Second, true)
+	<-ctx.Done()
+	assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+	assert.Equal(t, myErr, context.Cause(ctx))
+}
+
+func TestMockTime_ContextAfterFunc(t *testing.T) {
+	mt := NewMock()
+	ctx, cancel := mt.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var called int64
+	finished := make(chan struct{})
+	mt.ContextAfterFunc(ctx, func() {
+		atomic.AddInt64(&called, 1)
+		close(finished)
+	})
+	stop := mt.ContextAfterFunc(ctx, func() {
+		atomic.AddInt64(&called, 10)
+	})
+	assert.True(t, stop())
+	assert.False(t, stop())
+
+	mt.Advance(500*time.Millisecond, true)
+	assert.Equal(t, int64(0), atomic.LoadInt64(&called))
+	mt.Advance(time.Second, true)
+	<-finished
+	assert.Equal(t, int64(1), atomic.LoadInt64(&called))
+}
+
+func TestMockTime_ContextAfterFunc_Done(t *testing.T) {
+	mt := NewMock()
+	ctx, cancel := mt.WithCancel(context.Background())
+	cancel()
+
+	// f runs in its own goroutine even if ctx is already done,
+	// so the caller may hold a lock that f acquires
+	var lock sync.Mutex
+	finished := make(chan struct{})
+	lock.Lock()
+	stop := mt.ContextAfterFunc(ctx, func() {
+		lock.Lock()
+		defer lock.Unlock()
+		close(finished)
+	})
+	assert.False(t, stop())
+	lock.Unlock()
+	<-finished
+}
+
+func TestMockContext_ParentDeadline(t *testing.T) {
+	mt := NewMock()
+	now := mt.Now()
+
+	testcases := []struct {
+		name           string
+		parentDeadline time.Duration
+		childDeadline  time.Duration
+	}{
+		{name: "parent is earlier", parentDeadline: time.Second, childDeadline: time.Minute},
+		{name: "child is earlier", parentDeadline: time.Minute, childDeadline: time.Second},
+		{name: "same", parentDeadline: time.Second, childDeadline: time.Second},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Compare with the standard library. Deadlines are far enough from wall clock.
+			sparent, scancel := context.WithDeadline(context.Background(), now.Add(time.Hour+tc.parentDeadline))
+			defer scancel()
+			sctx, sccancel := context.WithDeadline(sparent, now.Add(time.Hour+tc.childDeadline))
+			defer sccancel()
+			expected, _ := sctx.Deadline()
+
+			parent, pcancel := mt.WithDeadline(context.Background(), now.Add(time.Hour+tc.parentDeadline))
+			defer pcancel()
+			ctx, cancel := mt.WithDeadline(parent, now.Add(time.Hour+tc.childDeadline))
+			defer cancel()
+			actual, ok := ctx.Deadline()
+			assert.True(t, ok)
+			assert.Equal(t, expected, actual)
+
+			// mock context with stdlib parent
+			ctx2, cancel2 := mt.WithDeadline(sparent, now.Add(time.Hour+tc.childDeadline))
+			defer cancel2()
+			actual2, ok := ctx2.Deadline()
+			assert.True(t, ok)
+			assert.Equal(t, expected, actual2)
+		})
+	}
+}
+
+func TestMockContext_NoTimerWhenParentIsSooner(t *testing.T) {
+	mt := NewMock()
+	parent, pcancel := mt.WithTimeout(context.Background(), time.Second)
+	defer pcancel()
+	assert.Equal(t, 1, len(mt.timers))
+
+	ctx, cancel := mt.WithTimeout(parent, time.Minute)
+	defer cancel()
+	assert.Equal(t, 1, len(mt.timers))
+
+	mt.Advance(time.Second, true)
+	assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+	assert.Equal(t, 0, len(mt.timers))
+}
+
+func TestMockContext_PastDeadline(t *testing.T) {
+	mt := NewMock()
+	sctx, scancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer scancel()
+
+	ctx, cancel := mt.WithDeadline(context.Background(), mt.Now().Add(-time.Second))
+	defer cancel()
+	<-ctx.Done()
+	assert.Equal(t, sctx.Err(), ctx.Err())
+	assert.Equal(t, context.Cause(sctx), context.Cause(ctx))
+	assert.Equal(t, 0, len(mt.timers))
+}
+
+func TestMockContext_ReleaseOnCancel(t *testing.T) {
+	mt := NewMock()
+	parent, pcancel := mt.WithCancel(context.Background())
+	defer pcancel()
+	sparent, scancel := context.WithCancel(context.Background())
+	defer scancel()
+
+	before := runtime.NumGoroutine()
+	for i := 0; i < 100; i++ {
+		_, cancel := mt.WithTimeout(parent, time.Second)
+		cancel()
+		_, cancel = mt.WithTimeout(sparent, time.Second)
+		cancel()
+	}
+	assert.Equal(t, 0, len(mt.timers))
+	assert.Equal(t, 0, len(parent.(*mockContext).afterFuncs))
+	assert.True(t, runtime.NumGoroutine() <= before)
+}
+
+func TestMockContext_ParentCanceledFirst(t *testing.T) {
+	mt := NewMock()
+	parent, pcancel := mt.WithCancelCause(context.Background())
+	myErr := errors.New("my error")
+	ctx, cancel := mt.WithTimeout(parent, time.Second)
+	defer cancel()
+	sctx, scancel := context.WithTimeout(parent, time.Hour)
+	defer scancel()
+
+	pcancel(myErr)
+	// propagated synchronously
+	select {
+	case <-ctx.Done():
+	default:
+		t.Fatal("context should be done")
+	}
+	assert.Equal(t, context.Canceled, ctx.Err())
+	assert.Equal(t, myErr, context.Cause(ctx))
+	<-sctx.Done()
+	assert.Equal(t, sctx.Err(), ctx.Err())
+	assert.Equal(t, context.Cause(sctx), context.Cause(ctx))
+	assert.Equal(t, 0, len(mt.timers))
+}
@@ -0,0 +1,162 @@
+// Package netconn provides net.Conn and net.Listener wrappers whose deadlines are enforced by itime.Time.
+//
+// The wrapped connection keeps the deadline of the underlying connection unset and sets it to the past
+// when the deadline passes on itime.Time, so blocked reads and writes wake up when itime.MockTime is advanced.
+package netconn
+
+import (
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/shibukawa/itime"
+)
+
+// aLongTimeAgo is a real deadline to interrupt blocked I/O of the underlying connection.
+var aLongTimeAgo = time.Unix(1, 0)
+
+// deadline is a deadline of one direction.
+type deadline struct {
+	time itime.Time
+	set  func(t time.Time) error
+
+	lock    sync.Mutex
+	gen     int
+	expired bool
+	timer   itime.Timer
+}
+
+func (d *deadline) reset(t time.Time) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.gen++
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	switch {
+	case t.IsZero():
+		d.expired = false
+		return d.set(time.Time{})
+	case !t.After(d.time.Now()):
+		d.expired = true
+		return d.set(aLongTimeAgo)
+	}
+	d.expired = false
+	gen := d.gen
+	d.timer = d.time.AfterFuncAt(t, func() {
+		d.lock.Lock()
+		defer d.lock.Unlock()
+		if gen != d.gen {
+			return
+		}
+		d.timer = nil
+		d.expired = true
+		d.set(aLongTimeAgo)
+	})
+	return d.set(time.Time{})
+}
+
+func (d *deadline) isExpired() bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.expired
+}
+
+func (d *deadline) stop() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.gen++
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+}
+
+// Conn is a net.Conn whose deadlines follow itime.Time.
+type Conn struct {
+	net.Conn
+	read  *deadline
+	write *deadline
+}
+
+var _ net.Conn = &Conn{}
+
+// Wrap wraps conn so that its deadlines are enforced by t.
+func Wrap(conn net.Conn, t itime.Time) *Conn {
+	if t == nil {
+		t = itime.New()
+	}
+	return &Conn{
+		Conn:  conn,
+		read:  &deadline{time: t, set: conn.SetReadDeadline},
+		write: &deadline{time: t, set: conn.SetWriteDeadline},
+	}
+}
+
+// Read reads from the underlying connection. It returns os.ErrDeadlineExceeded once the read deadline passes.
+func (c *Conn) Read(b []byte) (int, error) {
+	if c.read.isExpired() {
+		return 0, os.ErrDeadlineExceeded
+	}
+	return c.Conn.Read(b)
+}
+
+// Write writes to the underlying connection. It returns os.ErrDeadlineExceeded once the write deadline passes.
+func (c *Conn) Write(b []byte) (int, error) {
+	if c.write.isExpired() {
+		return 0, os.ErrDeadlineExceeded
+	}
+	return c.Conn.Write(b)
+}
+
+// SetDeadline sets both read and write deadlines on itime.Time.
+func (c *Conn) SetDeadline(t time.Time) error {
+	if err := c.read.reset(t); err != nil {
+		return err
+	}
+	return c.write.reset(t)
+}
+
+// SetReadDeadline sets the read deadline on itime.Time.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.read.reset(t)
+}
+
+// SetWriteDeadline sets the write deadline on itime.Time.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.write.reset(t)
+}
+
+// Close closes the underlying connection and stops deadline timers.
+func (c *Conn) Close() error {
+	c.read.stop()
+	c.write.stop()
+	return c.Conn.Close()
+}
+
+// Listener is a net.Listener that wraps accepted connections by Wrap.
+type Listener struct {
+	net.Listener
+	time itime.Time
+}
+
+var _ net.Listener = &Listener{}
+
+// WrapListener wraps l so that deadlines of accepted connections are enforced by t.
+func WrapListener(l net.Listener, t itime.Time) *Listener {
+	if t == nil {
+		t = itime.New()
+	}
+	return &Listener{Listener: l, time: t}
+}
+
+// Accept waits for the next connection and wraps it.
+func (l *Listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return Wrap(conn, l.time), nil
+}
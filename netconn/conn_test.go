@@ -0,0 +1,143 @@
+package netconn
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConn_ReadDeadline(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	c1, c2 := Pipe(mock)
+	defer c1.Close()
+	defer c2.Close()
+
+	assert.NoError(t, c1.SetReadDeadline(mock.Now().Add(time.Second)))
+	result := make(chan error)
+	go func() {
+		_, err := c1.Read(make([]byte, 1))
+		result <- err
+	}()
+
+	mock.Advance(999*time.Millisecond, true)
+	select {
+	case err := <-result:
+		t.Fatalf("read returned before the deadline: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	mock.Advance(time.Millisecond, true)
+	select {
+	case err := <-result:
+		assert.True(t, errors.Is(err, os.ErrDeadlineExceeded))
+	case <-time.After(time.Second):
+		t.Fatal("read didn't wake up")
+	}
+
+	// stays expired until the deadline is extended
+	_, err := c1.Read(make([]byte, 1))
+	assert.True(t, errors.Is(err, os.ErrDeadlineExceeded))
+	assert.NoError(t, c1.SetReadDeadline(time.Time{}))
+	go c2.Write([]byte("a"))
+	buf := make([]byte, 1)
+	n, err := c1.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", string(buf[:n]))
+}
+
+func TestConn_WriteDeadline(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	c1, c2 := Pipe(mock)
+	defer c1.Close()
+	defer c2.Close()
+
+	assert.NoError(t, c1.SetDeadline(mock.Now().Add(time.Second)))
+	result := make(chan error)
+	go func() {
+		// nobody reads from c2
+		_, err := c1.Write([]byte("hello"))
+		result <- err
+	}()
+	mock.Advance(time.Second, true)
+	select {
+	case err := <-result:
+		assert.True(t, errors.Is(err, os.ErrDeadlineExceeded))
+	case <-time.After(time.Second):
+		t.Fatal("write didn't wake up")
+	}
+}
+
+func TestConn_PastDeadline(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	c1, c2 := Pipe(mock)
+	defer c1.Close()
+	defer c2.Close()
+
+	assert.NoError(t, c1.SetReadDeadline(mock.Now()))
+	_, err := c1.Read(make([]byte, 1))
+	assert.True(t, errors.Is(err, os.ErrDeadlineExceeded))
+}
+
+func TestConn_RealClockDoesNotExpire(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	c1, c2 := Pipe(mock)
+	defer c1.Close()
+	defer c2.Close()
+
+	// deadline is far in the past of the real clock, but in the future of the mock clock
+	assert.NoError(t, c1.SetReadDeadline(mock.Now().Add(time.Nanosecond)))
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		c2.Write([]byte("a"))
+	}()
+	n, err := c1.Read(make([]byte, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestWrapListener(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	l := WrapListener(inner, mock)
+	defer l.Close()
+
+	go func() {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		if err == nil {
+			defer conn.Close()
+			io.Copy(io.Discard, conn)
+		}
+	}()
+	conn, err := l.Accept()
+	assert.NoError(t, err)
+	defer conn.Close()
+	_, ok := conn.(*Conn)
+	assert.True(t, ok)
+
+	conn.SetReadDeadline(mock.Now().Add(time.Minute))
+	result := make(chan error)
+	go func() {
+		_, err := conn.Read(make([]byte, 1))
+		result <- err
+	}()
+	mock.Advance(time.Minute, true)
+	select {
+	case err := <-result:
+		assert.True(t, errors.Is(err, os.ErrDeadlineExceeded))
+	case <-time.After(time.Second):
+		t.Fatal("read didn't wake up")
+	}
+}
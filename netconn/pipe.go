@@ -0,0 +1,93 @@
+package netconn
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/shibukawa/itime"
+)
+
+// Pipe creates a synchronous, in-memory, full duplex connection pair like net.Pipe,
+// whose deadlines are enforced by t.
+func Pipe(t itime.Time) (*Conn, *Conn) {
+	c1, c2 := net.Pipe()
+	return Wrap(c1, t), Wrap(c2, t)
+}
+
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string {
+	return "pipe"
+}
+
+func (pipeAddr) String() string {
+	return "pipe"
+}
+
+// PipeListener is an in-memory net.Listener. Connections are made by Dial or DialContext.
+type PipeListener struct {
+	time  itime.Time
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+var _ net.Listener = &PipeListener{}
+
+// ListenPipe creates PipeListener whose connections' deadlines are enforced by t.
+func ListenPipe(t itime.Time) *PipeListener {
+	if t == nil {
+		t = itime.New()
+	}
+	return &PipeListener{
+		time:  t,
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+}
+
+// Accept waits for the next connection made by Dial.
+func (l *PipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close closes the listener. Blocked Accept and Dial return net.ErrClosed.
+func (l *PipeListener) Close() error {
+	l.once.Do(func() {
+		close(l.done)
+	})
+	return nil
+}
+
+// Addr returns a dummy address.
+func (l *PipeListener) Addr() net.Addr {
+	return pipeAddr{}
+}
+
+// Dial connects to the listener.
+func (l *PipeListener) Dial() (net.Conn, error) {
+	return l.DialContext(context.Background(), "pipe", "pipe")
+}
+
+// DialContext connects to the listener. It ignores network and address, so it can be used as DialContext of http.Transport.
+func (l *PipeListener) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	client, server := Pipe(l.time)
+	var err error
+	select {
+	case l.conns <- server:
+		return client, nil
+	case <-l.done:
+		err = net.ErrClosed
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	client.Close()
+	server.Close()
+	return nil, err
+}
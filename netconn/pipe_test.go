@@ -0,0 +1,54 @@
+package netconn
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipeListener(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	l := ListenPipe(mock)
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(conn, conn)
+	}()
+
+	conn, err := l.Dial()
+	assert.NoError(t, err)
+	_, err = conn.Write([]byte("ping"))
+	assert.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(conn, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "ping", string(buf))
+	conn.Close()
+
+	assert.Equal(t, "pipe", l.Addr().Network())
+	assert.NoError(t, l.Close())
+	_, err = l.Accept()
+	assert.True(t, errors.Is(err, net.ErrClosed))
+	_, err = l.Dial()
+	assert.True(t, errors.Is(err, net.ErrClosed))
+}
+
+func TestPipeListener_DialCanceled(t *testing.T) {
+	l := ListenPipe(itime.NewMock())
+	defer l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := l.DialContext(ctx, "pipe", "pipe")
+	assert.Equal(t, context.Canceled, err)
+}
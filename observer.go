@@ -0,0 +1,127 @@
+package itime
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Kinds of ObservedEvent in addition to the ones of RecordedEvent
+const (
+	EventSleepEnd = "sleepend"
+	EventContext  = "context"
+	EventDeadline = "deadline"
+)
+
+// ObservedEvent is an event of timer lifecycle notified to Observer.
+//
+// ID identifies the timer for timer events, the Sleep call for EventSleep and EventSleepEnd,
+// and the context for EventContext and EventDeadline. It is unique only within a clock.
+// Clock identifies the GenuineTime or MockTime that notified the event.
+// Virtual is the time of the clock (same as Real for GenuineTime), Real is the wall clock time.
+// Duration is the duration of timers, Reset and Sleep, and the timeout of contexts.
+// Labels are the ones set by SetLabels of the clock.
+type ObservedEvent struct {
+	Kind     string
+	ID       int64
+	Clock    int64
+	Virtual  time.Time
+	Real     time.Time
+	Duration time.Duration
+	Labels   map[string]string
+}
+
+// Observer receives events of GenuineTime and MockTime.
+//
+// Observe is called synchronously by the goroutine that causes the event.
+// It may be called from several goroutines at the same time.
+type Observer interface {
+	Observe(e ObservedEvent)
+}
+
+// ObserverFunc is an adapter to use a function as Observer.
+type ObserverFunc func(e ObservedEvent)
+
+// Observe calls f(e).
+func (f ObserverFunc) Observe(e ObservedEvent) {
+	f(e)
+}
+
+type observerEntry struct {
+	o Observer
+}
+
+// clockID is the last Clock of ObservedEvent that was assigned.
+var clockID int64
+
+// observers is a list of observers shared by implementations of Time.
+type observers struct {
+	lock   sync.Mutex
+	list   []*observerEntry
+	labels map[string]string
+	clock  int64
+}
+
+func (o *observers) add(observer Observer) (remove func()) {
+	entry := &observerEntry{o: observer}
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	list := make([]*observerEntry, 0, len(o.list)+1)
+	list = append(list, o.list...)
+	o.list = append(list, entry)
+	return func() {
+		o.lock.Lock()
+		defer o.lock.Unlock()
+		list := make([]*observerEntry, 0, len(o.list))
+		for _, e := range o.list {
+			if e != entry {
+				list = append(list, e)
+			}
+		}
+		o.list = list
+	}
+}
+
+func (o *observers) setLabels(labels map[string]string) {
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.labels = copied
+}
+
+func (o *observers) active() bool {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return len(o.list) > 0
+}
+
+// emit notifies the event to all observers. Callers must not hold their locks
+// because observers may use the clock.
+func (o *observers) emit(kind string, id int64, virtual time.Time, d time.Duration) {
+	o.lock.Lock()
+	list := o.list
+	labels := o.labels
+	if len(list) > 0 && o.clock == 0 {
+		o.clock = atomic.AddInt64(&clockID, 1)
+	}
+	clock := o.clock
+	o.lock.Unlock()
+	if len(list) == 0 {
+		return
+	}
+	e := ObservedEvent{
+		Kind:     kind,
+		ID:       id,
+		Clock:    clock,
+		Virtual:  virtual,
+		Real:     time.Now(),
+		Duration: d,
+		Labels:   labels,
+	}
+	for _, entry := range list {
+		entry.o.Observe(e)
+	}
+}
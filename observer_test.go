@@ -0,0 +1,174 @@
+package itime
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type eventLog struct {
+	lock   sync.Mutex
+	events []ObservedEvent
+}
+
+func (l *eventLog) Observe(e ObservedEvent) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.events = append(l.events, e)
+}
+
+func (l *eventLog) kinds() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	var result []string
+	for _, e := range l.events {
+		result = append(result, e.Kind)
+	}
+	return result
+}
+
+// observed returns a channel that receives when an event of kind happens on m.
+func observed(m *MockTime, kind string) <-chan struct{} {
+	c := make(chan struct{}, 1)
+	m.AddObserver(ObserverFunc(func(e ObservedEvent) {
+		if e.Kind == kind {
+			select {
+			case c <- struct{}{}:
+			default:
+			}
+		}
+	}))
+	return c
+}
+
+func TestMockTime_Observer(t *testing.T) {
+	start := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	mock := NewMockWith(start)
+	defer mock.Close()
+
+	log := &eventLog{}
+	mock.AddObserver(log)
+	mock.SetLabels(map[string]string{"test": "observer"})
+
+	timer := mock.NewTimer(time.Second)
+	timer.Reset(2 * time.Second)
+	mock.Advance(2*time.Second, true)
+	<-timer.Chan()
+	mock.Now()
+
+	assert.Equal(t, []string{EventTimer, EventReset, EventFire, EventNow}, log.kinds())
+	fire := log.events[2]
+	assert.Equal(t, int64(1), fire.ID)
+	assert.Equal(t, start.Add(2*time.Second), fire.Virtual)
+	assert.Equal(t, "observer", fire.Labels["test"])
+	assert.Equal(t, 2*time.Second, log.events[1].Duration)
+}
+
+func TestMockTime_Observer_SleepAndContext(t *testing.T) {
+	start := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	mock := NewMockWith(start)
+	defer mock.Close()
+
+	log := &eventLog{}
+	mock.AddObserver(log)
+
+	ctx, cancel := mock.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		mock.Sleep(2 * time.Second)
+		close(done)
+	}()
+	for len(log.kinds()) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	mock.Advance(2*time.Second, true)
+	<-done
+	<-ctx.Done()
+
+	assert.Equal(t, []string{EventContext, EventSleep, EventDeadline, EventSleepEnd}, log.kinds())
+	assert.Equal(t, time.Second, log.events[0].Duration)
+	assert.Equal(t, start.Add(time.Second), log.events[2].Virtual)
+	assert.Equal(t, log.events[1].ID, log.events[3].ID)
+	assert.Equal(t, start.Add(2*time.Second), log.events[3].Virtual)
+}
+
+func TestMockTime_Observer_Multiple(t *testing.T) {
+	mock := NewMock()
+	defer mock.Close()
+
+	first := &eventLog{}
+	second := &eventLog{}
+	removeFirst := mock.AddObserver(first)
+	mock.AddObserver(second)
+
+	mock.Now()
+	removeFirst()
+	mock.Now()
+
+	assert.Equal(t, []string{EventNow}, first.kinds())
+	assert.Equal(t, []string{EventNow, EventNow}, second.kinds())
+}
+
+func TestGenuineTime_Observer(t *testing.T) {
+	genuine := New().(*GenuineTime)
+	defer genuine.Close()
+
+	log := &eventLog{}
+	genuine.AddObserver(log)
+
+	timer := genuine.NewTimer(time.Millisecond)
+	<-timer.Chan()
+	ticker := genuine.NewTicker(20 * time.Millisecond)
+	<-ticker.Chan()
+	ticker.Stop()
+	genuine.Sleep(time.Millisecond)
+
+	kinds := log.kinds()
+	assert.Equal(t, []string{EventTimer, EventFire, EventTicker, EventFire}, kinds[:4])
+	assert.Equal(t, []string{EventStop, EventSleep, EventSleepEnd}, kinds[len(kinds)-3:])
+	for _, e := range log.events {
+		assert.False(t, e.Real.IsZero())
+		assert.False(t, e.Virtual.IsZero())
+	}
+}
+
+func TestObserver_PastDeadline(t *testing.T) {
+	for name, clock := range map[string]Time{"genuine": New(), "mock": NewMock()} {
+		t.Run(name, func(t *testing.T) {
+			defer clock.Close()
+			deadline := make(chan struct{})
+			clock.(interface{ AddObserver(Observer) func() }).AddObserver(ObserverFunc(func(e ObservedEvent) {
+				if e.Kind == EventDeadline {
+					close(deadline)
+				}
+			}))
+
+			ctx, cancel := clock.WithTimeout(context.Background(), -time.Second)
+			defer cancel()
+			<-ctx.Done()
+			select {
+			case <-deadline:
+			case <-time.After(time.Second):
+				t.Fatal("EventDeadline is not notified for the past deadline")
+			}
+		})
+	}
+}
+
+func TestGenuineTime_Observer_TickerBeforeObserver(t *testing.T) {
+	genuine := New().(*GenuineTime)
+	defer genuine.Close()
+
+	ticker := genuine.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	log := &eventLog{}
+	genuine.AddObserver(log)
+	<-ticker.Chan()
+	<-ticker.Chan()
+
+	assert.Contains(t, log.kinds(), EventFire)
+}
@@ -0,0 +1,310 @@
+package itime
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultOffsetEnv is the default name of environment variable that NewOffsetFromEnv reads.
+const DefaultOffsetEnv = "ITIME_OFFSET"
+
+// OffsetTime is an implementation of Time interface that works like GenuineTime
+// (real durations and real timers), but its Now() is shifted by a fixed offset.
+//
+// It is useful to run services as if they live in another point in time
+// (e.g. end of month or year rollover) without touching the system clock.
+// Time values it returns from Now and timer channels are shifted.
+// Contexts keep real deadlines so that they can be passed to any API;
+// use LocalDeadline to read a deadline on the shifted clock.
+type OffsetTime struct {
+	offset time.Duration
+
+	lock   sync.Mutex
+	timers map[*offsetTimer]struct{}
+}
+
+// NewOffset returns OffsetTime instance whose Now() is shifted by offset from wall clock.
+func NewOffset(offset time.Duration) *OffsetTime {
+	return &OffsetTime{
+		offset: offset,
+		timers: make(map[*offsetTimer]struct{}),
+	}
+}
+
+// NewOffsetFrom returns OffsetTime instance whose Now() starts from start.
+func NewOffsetFrom(start time.Time) *OffsetTime {
+	return NewOffset(start.Sub(time.Now()))
+}
+
+// NewOffsetFromString returns OffsetTime instance configured by value.
+//
+// The value is either a start instant in RFC3339 format (e.g. "2027-12-31T23:59:00Z")
+// or an offset in time.ParseDuration format (e.g. "720h", "-1h30m").
+// An empty value means no offset.
+func NewOffsetFromString(value string) (*OffsetTime, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return NewOffset(0), nil
+	}
+	if start, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return NewOffsetFrom(start), nil
+	}
+	offset, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time offset %q: it should be RFC3339 time or duration", value)
+	}
+	return NewOffset(offset), nil
+}
+
+// NewOffsetFromEnv returns OffsetTime instance configured by environment variable.
+//
+// If name is empty, DefaultOffsetEnv is used. See NewOffsetFromString for the format of the value.
+func NewOffsetFromEnv(name string) (*OffsetTime, error) {
+	if name == "" {
+		name = DefaultOffsetEnv
+	}
+	return NewOffsetFromString(os.Getenv(name))
+}
+
+// Offset returns the difference between Now() and wall clock.
+func (o *OffsetTime) Offset() time.Duration {
+	return o.offset
+}
+
+// Now returns shifted wall clock time
+func (o *OffsetTime) Now() time.Time {
+	return time.Now().Add(o.offset)
+}
+
+// Close methods stops all internal timer
+func (o *OffsetTime) Close() {
+	o.lock.Lock()
+	timers := o.timers
+	o.timers = make(map[*offsetTimer]struct{})
+	o.lock.Unlock()
+	for timer := range timers {
+		timer.Stop()
+	}
+}
+
+func (o *OffsetTime) newTimer(d time.Duration, interval time.Duration, at time.Time, f func()) *offsetTimer {
+	r := &offsetTimer{
+		p:        o,
+		c:        make(chan time.Time, 1),
+		interval: interval,
+		at:       at,
+	}
+	o.lock.Lock()
+	o.timers[r] = struct{}{}
+	o.lock.Unlock()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.next = time.Now().Add(d)
+	r.t = time.AfterFunc(d, func() {
+		if r.early() {
+			return
+		}
+		if f != nil {
+			r.p.remove(r)
+			f()
+			return
+		}
+		r.fire()
+	})
+	return r
+}
+
+// NewTimer creates new oneshot timer
+func (o *OffsetTime) NewTimer(d time.Duration) Timer {
+	return o.newTimer(d, 0, time.Time{}, nil)
+}
+
+// NewTicker creates interval timer
+func (o *OffsetTime) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return o.newTimer(d, d, time.Time{}, nil)
+}
+
+// AfterFunc waits for the duration to elapse and then calls f in its own goroutine.
+func (o *OffsetTime) AfterFunc(d time.Duration, f func()) Timer {
+	return o.newTimer(d, 0, time.Time{}, f)
+}
+
+// After is a shorthand of creating Timer instance
+func (o *OffsetTime) After(d time.Duration) <-chan time.Time {
+	return o.NewTimer(d).Chan()
+}
+
+// Tick is a shorthand of creating Ticker instance
+func (o *OffsetTime) Tick(d time.Duration) <-chan time.Time {
+	return o.NewTicker(d).Chan()
+}
+
+// Sleep waits for the duration to elapse
+func (o *OffsetTime) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// NewTimerAt creates new oneshot timer that fires when shifted wall clock reaches t.
+func (o *OffsetTime) NewTimerAt(t time.Time) Timer {
+	return o.newTimerAt(t, nil)
+}
+
+// AfterFuncAt calls f in its own goroutine when shifted wall clock reaches t.
+func (o *OffsetTime) AfterFuncAt(t time.Time, f func()) Timer {
+	return o.newTimerAt(t, f)
+}
+
+func (o *OffsetTime) newTimerAt(t time.Time, f func()) *offsetTimer {
+	at := t.Add(-o.offset)
+	return o.newTimer(wallWait(untilWall(at)), 0, at, f)
+}
+
+// SleepUntil waits for shifted wall clock to reach t
+func (o *OffsetTime) SleepUntil(t time.Time) {
+	at := t.Add(-o.offset)
+	for d := untilWall(at); d > 0; d = untilWall(at) {
+		time.Sleep(wallWait(d))
+	}
+}
+
+// LocalDeadline returns the deadline of ctx on the shifted clock.
+func (o *OffsetTime) LocalDeadline(ctx context.Context) (deadline time.Time, ok bool) {
+	deadline, ok = ctx.Deadline()
+	if !ok {
+		return
+	}
+	return deadline.Add(o.offset), true
+}
+
+// WithCancel is the same as context.WithCancel
+func (o *OffsetTime) WithCancel(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithCancel(ctx)
+}
+
+// WithCancelCause is the same as context.WithCancelCause
+func (o *OffsetTime) WithCancelCause(ctx context.Context) (context.Context, context.CancelCauseFunc) {
+	return context.WithCancelCause(ctx)
+}
+
+// WithDeadline is the same as context.WithDeadline. d is a shifted time,
+// and the context reports the real deadline.
+func (o *OffsetTime) WithDeadline(ctx context.Context, d time.Time) (context.Context, context.CancelFunc) {
+	return context.WithDeadline(ctx, d.Add(-o.offset))
+}
+
+// WithDeadlineCause is the same as context.WithDeadlineCause. d is a shifted time,
+// and the context reports the real deadline.
+func (o *OffsetTime) WithDeadlineCause(ctx context.Context, d time.Time, cause error) (context.Context, context.CancelFunc) {
+	return context.WithDeadlineCause(ctx, d.Add(-o.offset), cause)
+}
+
+// WithTimeout is the same as context.WithTimeout
+func (o *OffsetTime) WithTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, d)
+}
+
+// WithTimeoutCause is the same as context.WithTimeoutCause
+func (o *OffsetTime) WithTimeoutCause(ctx context.Context, d time.Duration, cause error) (context.Context, context.CancelFunc) {
+	return context.WithTimeoutCause(ctx, d, cause)
+}
+
+// ContextAfterFunc is the same as context.AfterFunc
+func (o *OffsetTime) ContextAfterFunc(ctx context.Context, f func()) (stop func() bool) {
+	return context.AfterFunc(ctx, f)
+}
+
+func (o *OffsetTime) remove(t *offsetTimer) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	delete(o.timers, t)
+}
+
+var _ Time = &OffsetTime{}
+
+// offsetTimer is Timer and Ticker of OffsetTime. It sends shifted time to the channel.
+type offsetTimer struct {
+	p        *OffsetTime
+	t        *time.Timer
+	c        chan time.Time
+	interval time.Duration
+	next     time.Time
+	stopped  bool
+	lock     sync.Mutex
+
+	// at is the wall clock instant that timers created by NewTimerAt and AfterFuncAt wait for
+	at time.Time
+}
+
+// early re-arms the timer and returns true if it woke up before its wall clock instant.
+func (t *offsetTimer) early() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.at.IsZero() || t.stopped {
+		return false
+	}
+	if d := untilWall(t.at); d > 0 {
+		t.t.Reset(wallWait(d))
+		return true
+	}
+	return false
+}
+
+func (t *offsetTimer) fire() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.stopped {
+		return
+	}
+	select {
+	case t.c <- time.Now().Add(t.p.offset):
+	default:
+	}
+	if t.interval == 0 {
+		t.p.remove(t)
+		return
+	}
+	// reschedule from expected time to avoid drift
+	t.next = t.next.Add(t.interval)
+	d := time.Until(t.next)
+	if d < 0 {
+		t.next = time.Now()
+		d = 0
+	}
+	t.t.Reset(d)
+}
+
+// Reset changes timer duration
+func (t *offsetTimer) Reset(d time.Duration) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.p.lock.Lock()
+	t.p.timers[t] = struct{}{}
+	t.p.lock.Unlock()
+	t.next = time.Now().Add(d)
+	t.at = time.Time{}
+	t.stopped = false
+	return t.t.Reset(d)
+}
+
+// Stop stops timer
+func (t *offsetTimer) Stop() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.p.remove(t)
+	t.stopped = true
+	return t.t.Stop()
+}
+
+// Chan returns channel that sends shifted current time
+func (t *offsetTimer) Chan() <-chan time.Time {
+	return t.c
+}
+
+var _ Timer = &offsetTimer{}
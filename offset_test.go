@@ -0,0 +1,157 @@
+package itime
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOffset(t *testing.T) {
+	ot := NewOffset(24 * time.Hour)
+	defer ot.Close()
+
+	diff := ot.Now().Sub(time.Now().Add(24 * time.Hour))
+	assert.True(t, diff < time.Millisecond && diff > -time.Millisecond)
+	assert.Equal(t, 24*time.Hour, ot.Offset())
+}
+
+func TestNewOffsetFrom(t *testing.T) {
+	start := time.Date(2027, time.December, 31, 23, 59, 0, 0, time.UTC)
+	ot := NewOffsetFrom(start)
+	defer ot.Close()
+
+	assert.True(t, ot.Now().Sub(start) < time.Millisecond)
+}
+
+func TestNewOffsetFromString(t *testing.T) {
+	testcases := []struct {
+		name   string
+		value  string
+		offset time.Duration
+		start  time.Time
+		err    bool
+	}{
+		{name: "empty", value: "", offset: 0},
+		{name: "duration", value: "720h", offset: 720 * time.Hour},
+		{name: "negative duration", value: "-1h30m", offset: -90 * time.Minute},
+		{name: "start time", value: "2027-12-31T23:59:00Z", start: time.Date(2027, time.December, 31, 23, 59, 0, 0, time.UTC)},
+		{name: "invalid", value: "tomorrow", err: true},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ot, err := NewOffsetFromString(tc.value)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			if tc.start.IsZero() {
+				assert.Equal(t, tc.offset, ot.Offset())
+			} else {
+				assert.True(t, ot.Now().Sub(tc.start) < time.Second)
+			}
+		})
+	}
+}
+
+func TestNewOffsetFromEnv(t *testing.T) {
+	os.Setenv(DefaultOffsetEnv, "2027-12-31T23:59:00+09:00")
+	defer os.Unsetenv(DefaultOffsetEnv)
+
+	ot, err := NewOffsetFromEnv("")
+	assert.NoError(t, err)
+	assert.Equal(t, 2027, ot.Now().Year())
+}
+
+func TestOffsetTime_NewTimer(t *testing.T) {
+	ot := NewOffset(-24 * time.Hour)
+	defer ot.Close()
+
+	start := ot.Now()
+	timer := ot.NewTimer(2 * time.Millisecond)
+	now := <-timer.Chan()
+	elapsed := now.Sub(start)
+	assert.True(t, elapsed >= 2*time.Millisecond && elapsed < time.Second)
+	assert.False(t, timer.Stop())
+}
+
+func TestOffsetTime_NewTicker(t *testing.T) {
+	ot := NewOffset(time.Hour)
+	defer ot.Close()
+
+	ticker := ot.NewTicker(2 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		now := <-ticker.Chan()
+		assert.True(t, now.Sub(time.Now()) > 59*time.Minute)
+	}
+	assert.True(t, ticker.Stop())
+}
+
+func TestOffsetTime_AfterFunc(t *testing.T) {
+	ot := NewOffset(time.Hour)
+	defer ot.Close()
+
+	called := make(chan struct{})
+	ot.AfterFunc(time.Millisecond, func() {
+		close(called)
+	})
+	<-called
+}
+
+func TestOffsetTime_WithDeadline(t *testing.T) {
+	ot := NewOffset(365 * 24 * time.Hour)
+	defer ot.Close()
+
+	d := ot.Now().Add(5 * time.Millisecond)
+	ctx, cancel := ot.WithDeadline(context.Background(), d)
+	defer cancel()
+
+	// contexts report real deadline, LocalDeadline converts it to the shifted clock
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.Equal(t, d.Add(-ot.Offset()), deadline)
+	deadline, ok = ot.LocalDeadline(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, d, deadline)
+
+	child, ccancel := ot.WithTimeout(ctx, time.Hour)
+	defer ccancel()
+	deadline, ok = ot.LocalDeadline(child)
+	assert.True(t, ok)
+	assert.Equal(t, d, deadline)
+	_, ok = ot.LocalDeadline(context.Background())
+	assert.False(t, ok)
+
+	// real deadline works with contexts that aren't created by OffsetTime
+	std, scancel := context.WithTimeout(ctx, time.Hour)
+	defer scancel()
+	deadline, _ = std.Deadline()
+	assert.Equal(t, d.Add(-ot.Offset()), deadline)
+
+	<-child.Done()
+	assert.Equal(t, context.DeadlineExceeded, child.Err())
+	assert.False(t, ot.Now().Before(d))
+}
+
+func TestLocalDeadline(t *testing.T) {
+	ot := NewOffset(time.Hour)
+	defer ot.Close()
+	d := ot.Now().Add(time.Minute)
+	ctx, cancel := ot.WithDeadline(context.Background(), d)
+	defer cancel()
+	deadline, ok := LocalDeadline(ctx, ot)
+	assert.True(t, ok)
+	assert.Equal(t, d, deadline)
+
+	mock := NewMock()
+	defer mock.Close()
+	d = mock.Now().Add(time.Minute)
+	ctx, cancel = mock.WithDeadline(context.Background(), d)
+	defer cancel()
+	deadline, ok = LocalDeadline(ctx, mock)
+	assert.True(t, ok)
+	assert.True(t, d.Equal(deadline))
+}
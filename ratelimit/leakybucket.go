@@ -0,0 +1,154 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/shibukawa/itime"
+)
+
+// LeakyBucket is a leaky-bucket rate limiter that works as a queue.
+//
+// Events leak out one by one at an even interval of 1/limit, so it smooths bursts out.
+// Up to capacity events can wait in the queue. Allow admits an event only if it can leak now.
+type LeakyBucket struct {
+	time itime.Time
+
+	lock     sync.Mutex
+	limit    Limit
+	capacity int
+	// next is the earliest time when the next event can leak
+	next time.Time
+}
+
+// NewLeakyBucket creates empty LeakyBucket.
+func NewLeakyBucket(t itime.Time, limit Limit, capacity int) *LeakyBucket {
+	return &LeakyBucket{
+		time:     t,
+		limit:    limit,
+		capacity: capacity,
+		next:     t.Now(),
+	}
+}
+
+// Limit returns current limit.
+func (b *LeakyBucket) Limit() Limit {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.limit
+}
+
+// Capacity returns the number of events that can wait in the queue.
+func (b *LeakyBucket) Capacity() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.capacity
+}
+
+// SetLimit changes the leak rate. Queued events keep their time to leak.
+func (b *LeakyBucket) SetLimit(limit Limit) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.limit = limit
+}
+
+// SetCapacity changes the size of the queue.
+func (b *LeakyBucket) SetCapacity(capacity int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.capacity = capacity
+}
+
+// Allow reports whether an event can leak now.
+func (b *LeakyBucket) Allow() bool {
+	return b.AllowN(1)
+}
+
+// AllowN reports whether n events can leak now. n events occupy n intervals.
+func (b *LeakyBucket) AllowN(n int) bool {
+	r, _ := b.reserve(n, 0)
+	return r.ok
+}
+
+// Reserve puts an event into the queue.
+func (b *LeakyBucket) Reserve() *Reservation {
+	return b.ReserveN(1)
+}
+
+// ReserveN puts n events into the queue. The reservation is not OK if the queue is full.
+func (b *LeakyBucket) ReserveN(n int) *Reservation {
+	r, _ := b.reserve(n, InfDuration)
+	return r
+}
+
+// Wait blocks until an event leaks or ctx is done.
+func (b *LeakyBucket) Wait(ctx context.Context) error {
+	return b.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n events leak or ctx is done.
+//
+// It returns ErrExceedsDeadline without waiting if the events would leak after ctx's deadline.
+func (b *LeakyBucket) WaitN(ctx context.Context, n int) error {
+	maxWait := InfDuration
+	if deadline, ok := itime.LocalDeadline(ctx, b.time); ok {
+		maxWait = deadline.Sub(b.time.Now())
+	}
+	r, err := b.reserve(n, maxWait)
+	if err != nil {
+		return err
+	}
+	return wait(ctx, b.time, r)
+}
+
+// reserve reserves n events if they leak within maxWait. The error tells why it is not OK.
+func (b *LeakyBucket) reserve(n int, maxWait time.Duration) (*Reservation, error) {
+	now := b.time.Now()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	r := &Reservation{time: b.time, timeToAct: now, cancel: b.cancel}
+	if b.limit == Inf {
+		r.ok = true
+		return r, nil
+	}
+	interval := b.limit.interval()
+	if interval == InfDuration {
+		return r, ErrExceedsLimit
+	}
+	start := b.next
+	if start.Before(now) {
+		start = now
+	}
+	delay := start.Sub(now)
+	// events that leak before this one
+	if ahead := int((delay + interval - 1) / interval); ahead+n-1 > b.capacity {
+		return r, ErrExceedsLimit
+	}
+	// n events leak one by one. The reservation acts when the last one leaks.
+	timeToAct := start.Add(time.Duration(n-1) * interval)
+	if timeToAct.Sub(now) > maxWait {
+		return r, ErrExceedsDeadline
+	}
+	b.next = timeToAct.Add(interval)
+	r.ok = true
+	r.timeToAct = timeToAct
+	r.n = n
+	return r, nil
+}
+
+// cancel removes r from the queue if it is the last one and has not leaked yet.
+func (b *LeakyBucket) cancel(r *Reservation) {
+	now := b.time.Now()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.limit == Inf || !r.timeToAct.After(now) {
+		return
+	}
+	interval := b.limit.interval()
+	if b.next.Equal(r.timeToAct.Add(interval)) {
+		b.next = b.next.Add(-time.Duration(r.n) * interval)
+	}
+}
+
+var _ Limiter = &LeakyBucket{}
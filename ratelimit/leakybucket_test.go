@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeakyBucket_Allow(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+
+	b := NewLeakyBucket(mock, 10, 0)
+	// no burst
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow())
+	mock.Advance(50*time.Millisecond, true)
+	assert.False(t, b.Allow())
+	mock.Advance(50*time.Millisecond, true)
+	assert.True(t, b.Allow())
+}
+
+func TestLeakyBucket_Reserve(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+
+	b := NewLeakyBucket(mock, Every(100*time.Millisecond), 2)
+	var delays []time.Duration
+	for i := 0; i < 3; i++ {
+		r := b.Reserve()
+		assert.True(t, r.OK())
+		delays = append(delays, r.Delay())
+	}
+	assert.Equal(t, []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond}, delays)
+
+	// the queue is full
+	assert.False(t, b.Reserve().OK())
+
+	// the last one can be canceled
+	mock.Advance(100*time.Millisecond, true)
+	last := b.Reserve()
+	assert.Equal(t, 200*time.Millisecond, last.Delay())
+	last.Cancel()
+	assert.Equal(t, 200*time.Millisecond, b.Reserve().Delay())
+}
+
+func TestLeakyBucket_Wait(t *testing.T) {
+	start := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	mock := itime.NewMockWith(start)
+	defer mock.Close()
+	mock.StartAutoAdvance(itime.AutoAdvanceOption{})
+
+	b := NewLeakyBucket(mock, Every(time.Second), 10)
+	var admitted []time.Duration
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, b.Wait(context.Background()))
+		admitted = append(admitted, mock.Now().Sub(start))
+		if i == 0 {
+			b.SetLimit(Every(2 * time.Second))
+		}
+	}
+	// the slot after the first one was computed with the old limit
+	assert.Equal(t, []time.Duration{0, time.Second, 3 * time.Second}, admitted)
+
+	ctx, cancel := mock.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Equal(t, ErrExceedsDeadline, b.Wait(ctx))
+
+	b.SetCapacity(0)
+	assert.Equal(t, 0, b.Capacity())
+	assert.Equal(t, ErrExceedsLimit, b.Wait(context.Background()))
+}
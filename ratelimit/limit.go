@@ -0,0 +1,145 @@
+// Package ratelimit provides token-bucket and leaky-bucket rate limiters that read time via itime.Time.
+//
+// Unlike golang.org/x/time/rate, tests can advance itime.MockTime and observe exactly which requests are admitted.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/shibukawa/itime"
+)
+
+// Limit is the maximum frequency of events in events per second.
+type Limit float64
+
+// Inf is the infinite rate limit. All events are allowed.
+const Inf = Limit(math.MaxFloat64)
+
+// InfDuration is the delay returned by Reservation that is not OK.
+const InfDuration = time.Duration(math.MaxInt64)
+
+// Every converts the minimum interval between events to Limit.
+func Every(interval time.Duration) Limit {
+	if interval <= 0 {
+		return Inf
+	}
+	return 1 / Limit(interval.Seconds())
+}
+
+// interval returns the duration between events. Zero limit returns InfDuration.
+func (l Limit) interval() time.Duration {
+	if l <= 0 {
+		return InfDuration
+	}
+	return time.Duration(float64(time.Second) / float64(l))
+}
+
+var (
+	// ErrExceedsLimit is returned by Wait when the request can never be admitted (e.g. n exceeds burst).
+	ErrExceedsLimit = errors.New("ratelimit: request exceeds limit")
+	// ErrExceedsDeadline is returned by Wait when the request would be admitted after the context's deadline.
+	ErrExceedsDeadline = errors.New("ratelimit: request would exceed context deadline")
+)
+
+// Limiter is the common interface of TokenBucket and LeakyBucket.
+type Limiter interface {
+	// Allow reports whether an event may happen now.
+	Allow() bool
+	// AllowN reports whether n events may happen now.
+	AllowN(n int) bool
+	// Reserve reserves an event and returns when it may happen.
+	Reserve() *Reservation
+	// ReserveN reserves n events and returns when they may happen.
+	ReserveN(n int) *Reservation
+	// Wait blocks until an event may happen or ctx is done.
+	Wait(ctx context.Context) error
+	// WaitN blocks until n events may happen or ctx is done.
+	WaitN(ctx context.Context, n int) error
+	// Limit returns current limit.
+	Limit() Limit
+	// SetLimit changes the limit. Reserved events are not affected.
+	SetLimit(limit Limit)
+}
+
+// Reservation holds events reserved by ReserveN.
+type Reservation struct {
+	ok        bool
+	timeToAct time.Time
+	n         int
+	time      itime.Time
+
+	lock     sync.Mutex
+	canceled bool
+	cancel   func(r *Reservation)
+}
+
+// OK reports whether the events can be admitted. If it is false, the reservation doesn't consume the limit.
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// TimeToAct returns the time when the events may happen.
+func (r *Reservation) TimeToAct() time.Time {
+	return r.timeToAct
+}
+
+// Delay returns how long to wait until the events may happen. It returns InfDuration if it is not OK.
+func (r *Reservation) Delay() time.Duration {
+	return r.DelayFrom(r.time.Now())
+}
+
+// DelayFrom returns how long to wait from now until the events may happen.
+func (r *Reservation) DelayFrom(now time.Time) time.Duration {
+	if !r.ok {
+		return InfDuration
+	}
+	d := r.timeToAct.Sub(now)
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// Cancel gives the reserved events back to the limiter as much as possible if they have not happened yet.
+func (r *Reservation) Cancel() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !r.ok || r.canceled {
+		return
+	}
+	r.canceled = true
+	r.cancel(r)
+}
+
+// wait waits for r via clock. It cancels r if it can't wait for it.
+func wait(ctx context.Context, clock itime.Time, r *Reservation) error {
+	if !r.ok {
+		return ErrExceedsLimit
+	}
+	if err := ctx.Err(); err != nil {
+		r.Cancel()
+		return err
+	}
+	now := clock.Now()
+	delay := r.DelayFrom(now)
+	if delay == 0 {
+		return nil
+	}
+	if deadline, ok := itime.LocalDeadline(ctx, clock); ok && deadline.Before(r.timeToAct) {
+		r.Cancel()
+		return ErrExceedsDeadline
+	}
+	timer := clock.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.Chan():
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
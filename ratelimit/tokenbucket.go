@@ -0,0 +1,211 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/shibukawa/itime"
+)
+
+// TokenBucket is a token-bucket rate limiter.
+//
+// The bucket holds up to burst tokens and is refilled at limit tokens per second.
+// Each event consumes one token, so bursts up to burst events are admitted at once.
+type TokenBucket struct {
+	time itime.Time
+
+	lock   sync.Mutex
+	limit  Limit
+	burst  int
+	tokens float64
+	last   time.Time
+	// lastEvent is the latest time to act of reservations
+	lastEvent time.Time
+}
+
+// NewTokenBucket creates TokenBucket that starts with a full bucket.
+func NewTokenBucket(t itime.Time, limit Limit, burst int) *TokenBucket {
+	return &TokenBucket{
+		time:   t,
+		limit:  limit,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   t.Now(),
+	}
+}
+
+// Limit returns current limit.
+func (b *TokenBucket) Limit() Limit {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.limit
+}
+
+// Burst returns current bucket size.
+func (b *TokenBucket) Burst() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.burst
+}
+
+// Tokens returns the number of tokens available now. It is negative while events are reserved in advance.
+func (b *TokenBucket) Tokens() float64 {
+	now := b.time.Now()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	tokens, _ := b.advance(now)
+	return tokens
+}
+
+// SetLimit changes the refill rate. Tokens refilled until now are kept.
+func (b *TokenBucket) SetLimit(limit Limit) {
+	now := b.time.Now()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.tokens, b.last = b.advance(now)
+	b.limit = limit
+}
+
+// SetBurst changes the bucket size.
+func (b *TokenBucket) SetBurst(burst int) {
+	now := b.time.Now()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.tokens, b.last = b.advance(now)
+	b.burst = burst
+	if b.tokens > float64(burst) {
+		b.tokens = float64(burst)
+	}
+}
+
+// Allow reports whether an event may happen now.
+func (b *TokenBucket) Allow() bool {
+	return b.AllowN(1)
+}
+
+// AllowN reports whether n events may happen now. If so, they consume tokens.
+func (b *TokenBucket) AllowN(n int) bool {
+	r, _ := b.reserve(n, 0)
+	return r.ok
+}
+
+// Reserve reserves an event.
+func (b *TokenBucket) Reserve() *Reservation {
+	return b.ReserveN(1)
+}
+
+// ReserveN reserves n events. The reservation is not OK if n exceeds burst.
+func (b *TokenBucket) ReserveN(n int) *Reservation {
+	r, _ := b.reserve(n, InfDuration)
+	return r
+}
+
+// Wait blocks until an event may happen or ctx is done.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	return b.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n events may happen or ctx is done.
+//
+// It returns ErrExceedsDeadline without waiting if the events would happen after ctx's deadline.
+func (b *TokenBucket) WaitN(ctx context.Context, n int) error {
+	maxWait := InfDuration
+	if deadline, ok := itime.LocalDeadline(ctx, b.time); ok {
+		maxWait = deadline.Sub(b.time.Now())
+	}
+	r, err := b.reserve(n, maxWait)
+	if err != nil {
+		return err
+	}
+	return wait(ctx, b.time, r)
+}
+
+// advance returns tokens and the time refilled until now. Caller must hold b.lock.
+func (b *TokenBucket) advance(now time.Time) (float64, time.Time) {
+	last := b.last
+	if now.Before(last) {
+		// the clock is rewound
+		last = now
+	}
+	tokens := b.tokens
+	if b.limit > 0 {
+		tokens += now.Sub(last).Seconds() * float64(b.limit)
+	}
+	if tokens > float64(b.burst) {
+		tokens = float64(b.burst)
+	}
+	return tokens, now
+}
+
+// reserve reserves n events if they can happen within maxWait. The error tells why it is not OK.
+func (b *TokenBucket) reserve(n int, maxWait time.Duration) (*Reservation, error) {
+	now := b.time.Now()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	r := &Reservation{time: b.time, timeToAct: now, cancel: b.cancel}
+	if b.limit == Inf {
+		r.ok = true
+		return r, nil
+	}
+	if n > b.burst {
+		return r, ErrExceedsLimit
+	}
+	tokens, last := b.advance(now)
+	tokens -= float64(n)
+	var delay time.Duration
+	if tokens < 0 {
+		if b.limit <= 0 {
+			return r, ErrExceedsLimit
+		}
+		seconds := -tokens / float64(b.limit)
+		if seconds*float64(time.Second) >= math.MaxInt64 {
+			return r, ErrExceedsLimit
+		}
+		delay = time.Duration(math.Ceil(seconds * float64(time.Second)))
+	}
+	if delay > maxWait {
+		return r, ErrExceedsDeadline
+	}
+	b.tokens = tokens
+	b.last = last
+	r.ok = true
+	r.timeToAct = now.Add(delay)
+	r.n = n
+	b.lastEvent = r.timeToAct
+	return r, nil
+}
+
+// cancel gives tokens of r back if r has not acted yet.
+//
+// Tokens that later reservations have counted on are not restored, otherwise the next
+// reservation would be scheduled together with them.
+func (b *TokenBucket) cancel(r *Reservation) {
+	now := b.time.Now()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.limit == Inf || !r.timeToAct.After(now) {
+		return
+	}
+	restore := float64(r.n)
+	if b.limit > 0 && b.lastEvent.After(r.timeToAct) {
+		restore -= b.lastEvent.Sub(r.timeToAct).Seconds() * float64(b.limit)
+	}
+	if restore <= 0 {
+		return
+	}
+	b.tokens, b.last = b.advance(now)
+	b.tokens += restore
+	if b.tokens > float64(b.burst) {
+		b.tokens = float64(b.burst)
+	}
+	if r.timeToAct.Equal(b.lastEvent) && b.limit > 0 {
+		prev := r.timeToAct.Add(-time.Duration(float64(r.n) / float64(b.limit) * float64(time.Second)))
+		if !prev.Before(now) {
+			b.lastEvent = prev
+		}
+	}
+}
+
+var _ Limiter = &TokenBucket{}
@@ -0,0 +1,127 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket_Allow(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+
+	b := NewTokenBucket(mock, 2, 3)
+	// burst
+	assert.True(t, b.Allow())
+	assert.True(t, b.Allow())
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow())
+
+	// refilled by 2 tokens per second
+	mock.Advance(500*time.Millisecond, true)
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow())
+
+	mock.Advance(time.Hour, true)
+	assert.Equal(t, 3.0, b.Tokens())
+	assert.False(t, b.AllowN(4))
+	assert.True(t, b.AllowN(3))
+}
+
+func TestTokenBucket_Reserve(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+
+	b := NewTokenBucket(mock, Every(time.Second), 1)
+	assert.Equal(t, time.Duration(0), b.Reserve().Delay())
+	r := b.Reserve()
+	assert.True(t, r.OK())
+	assert.Equal(t, time.Second, r.Delay())
+	assert.Equal(t, 2*time.Second, b.Reserve().Delay())
+
+	// canceled tokens can be used by others
+	r2 := b.Reserve()
+	r2.Cancel()
+	assert.Equal(t, 3*time.Second, b.Reserve().Delay())
+
+	assert.False(t, b.ReserveN(2).OK())
+	assert.Equal(t, InfDuration, b.ReserveN(2).Delay())
+}
+
+func TestTokenBucket_CancelBeforeLater(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+
+	b := NewTokenBucket(mock, Every(time.Second), 1)
+	b.Reserve()
+	middle := b.Reserve()
+	assert.Equal(t, time.Second, middle.Delay())
+	last := b.Reserve()
+	assert.Equal(t, 2*time.Second, last.Delay())
+
+	// the later reservation already counts on the canceled tokens
+	middle.Cancel()
+	assert.Equal(t, 3*time.Second, b.Reserve().Delay())
+}
+
+func TestTokenBucket_SetLimit(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+
+	b := NewTokenBucket(mock, 1, 1)
+	assert.True(t, b.Allow())
+	mock.Advance(500*time.Millisecond, true)
+	b.SetLimit(10)
+	assert.Equal(t, Limit(10), b.Limit())
+	mock.Advance(50*time.Millisecond, true)
+	// 0.5 tokens by old limit + 0.5 tokens by new limit
+	assert.True(t, b.Allow())
+
+	b.SetLimit(Inf)
+	for i := 0; i < 100; i++ {
+		assert.True(t, b.Allow())
+	}
+}
+
+func TestTokenBucket_Wait(t *testing.T) {
+	start := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	mock := itime.NewMockWith(start)
+	defer mock.Close()
+	mock.StartAutoAdvance(itime.AutoAdvanceOption{})
+
+	b := NewTokenBucket(mock, Every(time.Second), 2)
+	var admitted []time.Duration
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, b.Wait(context.Background()))
+		admitted = append(admitted, mock.Now().Sub(start))
+	}
+	assert.Equal(t, []time.Duration{0, 0, time.Second, 2 * time.Second}, admitted)
+
+	ctx, cancel := mock.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, ErrExceedsDeadline, b.Wait(ctx))
+	assert.Equal(t, ErrExceedsLimit, b.WaitN(context.Background(), 3))
+}
+
+func TestTokenBucket_WaitCanceled(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+
+	b := NewTokenBucket(mock, Every(time.Second), 1)
+	b.Allow()
+	ctx, cancel := mock.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- b.Wait(ctx)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+
+	// the canceled reservation gives its token back
+	mock.Advance(time.Second, true)
+	assert.True(t, b.Allow())
+}
@@ -0,0 +1,290 @@
+package itime
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Kinds of RecordedEvent
+const (
+	EventNow       = "now"
+	EventTimer     = "timer"
+	EventTicker    = "ticker"
+	EventAfterFunc = "afterfunc"
+	EventSleep     = "sleep"
+	EventFire      = "fire"
+	EventReset     = "reset"
+	EventStop      = "stop"
+)
+
+// RecordedEvent is an entry of a recording written by RecordingTime.
+//
+// ID identifies the timer (timer, ticker, AfterFunc or Sleep). Timers are numbered in creation order from 1.
+// Time is the reading of Now() for EventNow, the time of firing for EventFire,
+// and the time of the operation for others.
+type RecordedEvent struct {
+	Seq      int64         `json:"seq"`
+	Kind     string        `json:"kind"`
+	ID       int64         `json:"id,omitempty"`
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+// RecordingTime is a decorator of Time (usually GenuineTime) that logs every Now() reading,
+// timer and ticker creation, fire, reset and stop as JSON lines.
+//
+// The recording can be replayed with NewReplay. Contexts are not recorded.
+type RecordingTime struct {
+	Time
+
+	lock    sync.Mutex
+	enc     *json.Encoder
+	seq     int64
+	timerID int64
+	err     error
+	tickers map[*recordingTicker]struct{}
+}
+
+// NewRecorder returns RecordingTime that writes events of base to w.
+func NewRecorder(base Time, w io.Writer) *RecordingTime {
+	return &RecordingTime{
+		Time:    base,
+		enc:     json.NewEncoder(w),
+		tickers: make(map[*recordingTicker]struct{}),
+	}
+}
+
+// Err returns the first error of writing.
+func (r *RecordingTime) Err() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.err
+}
+
+func (r *RecordingTime) newID() int64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.timerID++
+	return r.timerID
+}
+
+func (r *RecordingTime) record(kind string, id int64, t time.Time, d time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.seq++
+	err := r.enc.Encode(RecordedEvent{
+		Seq:      r.seq,
+		Kind:     kind,
+		ID:       id,
+		Time:     t,
+		Duration: d,
+	})
+	if err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+// Now returns current time of base and records it.
+func (r *RecordingTime) Now() time.Time {
+	now := r.Time.Now()
+	r.record(EventNow, 0, now, 0)
+	return now
+}
+
+// Close stops all tickers and the base Time.
+func (r *RecordingTime) Close() {
+	r.lock.Lock()
+	tickers := r.tickers
+	r.tickers = make(map[*recordingTicker]struct{})
+	r.lock.Unlock()
+	for ticker := range tickers {
+		ticker.Stop()
+	}
+	r.Time.Close()
+}
+
+// NewTimer creates new oneshot timer that records its fire.
+func (r *RecordingTime) NewTimer(d time.Duration) Timer {
+	return r.newTimer(EventTimer, d, nil, func(f func()) Timer {
+		return r.Time.AfterFunc(d, f)
+	})
+}
+
+// NewTimerAt creates new oneshot timer that fires at t and records its fire.
+func (r *RecordingTime) NewTimerAt(t time.Time) Timer {
+	return r.newTimer(EventTimer, t.Sub(r.Time.Now()), nil, func(f func()) Timer {
+		return r.Time.AfterFuncAt(t, f)
+	})
+}
+
+// newTimer records creation of a timer and arms it by start. If f is nil, the timer sends to its channel.
+func (r *RecordingTime) newTimer(kind string, d time.Duration, f func(), start func(f func()) Timer) Timer {
+	t := &recordingTimer{
+		p:  r,
+		id: r.newID(),
+		c:  make(chan time.Time, 1),
+	}
+	r.record(kind, t.id, r.Time.Now(), d)
+	t.t = start(func() {
+		now := r.Time.Now()
+		r.record(EventFire, t.id, now, 0)
+		if f != nil {
+			f()
+			return
+		}
+		select {
+		case t.c <- now:
+		default:
+		}
+	})
+	return t
+}
+
+// NewTicker creates interval timer that records its ticks.
+func (r *RecordingTime) NewTicker(d time.Duration) Ticker {
+	t := &recordingTicker{
+		p:    r,
+		id:   r.newID(),
+		c:    make(chan time.Time, 1),
+		stop: make(chan struct{}),
+	}
+	r.record(EventTicker, t.id, r.Time.Now(), d)
+	t.t = r.Time.NewTicker(d)
+	r.lock.Lock()
+	r.tickers[t] = struct{}{}
+	r.lock.Unlock()
+	go t.run()
+	return t
+}
+
+// AfterFunc waits for the duration to elapse and then calls f. The fire is recorded.
+func (r *RecordingTime) AfterFunc(d time.Duration, f func()) Timer {
+	return r.newTimer(EventAfterFunc, d, f, func(f func()) Timer {
+		return r.Time.AfterFunc(d, f)
+	})
+}
+
+// AfterFuncAt calls f at t. The fire is recorded.
+func (r *RecordingTime) AfterFuncAt(t time.Time, f func()) Timer {
+	return r.newTimer(EventAfterFunc, t.Sub(r.Time.Now()), f, func(f func()) Timer {
+		return r.Time.AfterFuncAt(t, f)
+	})
+}
+
+// After is a shorthand of creating Timer instance.
+func (r *RecordingTime) After(d time.Duration) <-chan time.Time {
+	return r.NewTimer(d).Chan()
+}
+
+// Tick is a shorthand of creating Ticker instance.
+func (r *RecordingTime) Tick(d time.Duration) <-chan time.Time {
+	return r.NewTicker(d).Chan()
+}
+
+// Sleep waits for the duration to elapse. Its start and wake-up are recorded.
+func (r *RecordingTime) Sleep(d time.Duration) {
+	id := r.newID()
+	r.record(EventSleep, id, r.Time.Now(), d)
+	r.Time.Sleep(d)
+	r.record(EventFire, id, r.Time.Now(), 0)
+}
+
+// SleepUntil waits for t. Its start and wake-up are recorded.
+func (r *RecordingTime) SleepUntil(t time.Time) {
+	id := r.newID()
+	r.record(EventSleep, id, r.Time.Now(), t.Sub(r.Time.Now()))
+	r.Time.SleepUntil(t)
+	r.record(EventFire, id, r.Time.Now(), 0)
+}
+
+var _ Time = &RecordingTime{}
+
+type recordingTimer struct {
+	p  *RecordingTime
+	id int64
+	t  Timer
+	c  chan time.Time
+}
+
+func (t *recordingTimer) Reset(d time.Duration) bool {
+	t.p.record(EventReset, t.id, t.p.Time.Now(), d)
+	return t.t.Reset(d)
+}
+
+func (t *recordingTimer) Stop() bool {
+	t.p.record(EventStop, t.id, t.p.Time.Now(), 0)
+	return t.t.Stop()
+}
+
+func (t *recordingTimer) Chan() <-chan time.Time {
+	return t.c
+}
+
+var _ Timer = &recordingTimer{}
+
+type recordingTicker struct {
+	p    *RecordingTime
+	id   int64
+	t    Ticker
+	c    chan time.Time
+	stop chan struct{}
+	once sync.Once
+}
+
+func (t *recordingTicker) run() {
+	for {
+		select {
+		case now := <-t.t.Chan():
+			t.p.record(EventFire, t.id, now, 0)
+			select {
+			case t.c <- now:
+			default:
+			}
+		case <-t.stop:
+			return
+		}
+	}
+}
+
+func (t *recordingTicker) Stop() bool {
+	stopped := false
+	t.once.Do(func() {
+		t.p.record(EventStop, t.id, t.p.Time.Now(), 0)
+		close(t.stop)
+		t.t.Stop()
+		stopped = true
+	})
+	t.p.lock.Lock()
+	delete(t.p.tickers, t)
+	t.p.lock.Unlock()
+	return stopped
+}
+
+func (t *recordingTicker) Chan() <-chan time.Time {
+	return t.c
+}
+
+var _ Ticker = &recordingTicker{}
+
+// ReadRecording reads events written by RecordingTime.
+func ReadRecording(r io.Reader) ([]RecordedEvent, error) {
+	var events []RecordedEvent
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e RecordedEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,101 @@
+package itime
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// scenario reads time and waits timers. It returns the observed times.
+func recordScenario(t Time) []time.Time {
+	var result []time.Time
+	result = append(result, t.Now())
+	timer := t.NewTimer(2 * time.Millisecond)
+	result = append(result, <-timer.Chan())
+
+	ticker := t.NewTicker(5 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		result = append(result, <-ticker.Chan())
+	}
+	ticker.Stop()
+
+	fired := make(chan time.Time, 1)
+	t.AfterFunc(time.Millisecond, func() {
+		fired <- t.Now()
+	})
+	result = append(result, <-fired)
+
+	t.Sleep(time.Millisecond)
+	result = append(result, t.Now())
+	return result
+}
+
+func TestRecordingTime(t *testing.T) {
+	var buf bytes.Buffer
+	rt := NewRecorder(New(), &buf)
+	recordScenario(rt)
+	rt.Close()
+	assert.NoError(t, rt.Err())
+
+	events, err := ReadRecording(&buf)
+	assert.NoError(t, err)
+	var kinds []string
+	for _, e := range events {
+		kinds = append(kinds, e.Kind)
+	}
+	assert.Equal(t, strings.Join([]string{
+		EventNow,
+		EventTimer, EventFire,
+		EventTicker, EventFire, EventFire, EventFire, EventStop,
+		EventAfterFunc, EventFire, EventNow,
+		EventSleep, EventFire, EventNow,
+	}, ","), strings.Join(kinds, ","))
+	for i, e := range events {
+		assert.Equal(t, int64(i+1), e.Seq)
+	}
+}
+
+func TestNewReplay(t *testing.T) {
+	var buf bytes.Buffer
+	rt := NewRecorder(New(), &buf)
+	recorded := recordScenario(rt)
+	rt.Close()
+
+	events, err := ReadRecording(&buf)
+	assert.NoError(t, err)
+
+	mock := NewReplay(events)
+	defer mock.Close()
+	mock.StartAutoAdvance(AutoAdvanceOption{})
+
+	replayed := recordScenario(mock)
+	assert.Equal(t, len(recorded), len(replayed))
+	for i := range recorded {
+		assert.True(t, recorded[i].Equal(replayed[i]), "%d: %v != %v", i, recorded[i], replayed[i])
+	}
+}
+
+func TestNewReplay_Diverged(t *testing.T) {
+	start := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	// the recorded fire is earlier than the timer of the replayed run can fire
+	mock := NewReplay([]RecordedEvent{
+		{Seq: 1, Kind: EventNow, Time: start},
+		{Seq: 2, Kind: EventTimer, ID: 1, Time: start, Duration: 100 * time.Millisecond},
+		{Seq: 3, Kind: EventFire, ID: 1, Time: start.Add(100 * time.Millisecond)},
+	})
+	defer mock.Close()
+
+	assert.True(t, start.Equal(mock.Now()))
+	timer := mock.NewTimer(time.Second)
+	mock.Advance(999*time.Millisecond, true)
+	select {
+	case <-timer.Chan():
+		t.Fatal("timer fired before its nominal time")
+	default:
+	}
+	mock.Advance(time.Millisecond, true)
+	assert.True(t, start.Add(time.Second).Equal(<-timer.Chan()))
+}
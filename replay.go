@@ -0,0 +1,88 @@
+package itime
+
+import (
+	"time"
+)
+
+type replayer struct {
+	readings []time.Time
+	timers   map[int64]*replayTimer
+}
+
+type replayTimer struct {
+	ticker bool
+	fires  []time.Time
+}
+
+// NewReplay returns MockTime that reproduces a recording of RecordingTime.
+//
+// It starts from the time of the first event. Now() returns recorded readings in order
+// and moves the clock to them. Timers are matched with recorded ones by creation order
+// and fire exactly at the recorded time. So the code under test must create timers in
+// the same order as the recorded run. Timers that didn't fire in the recording, or whose
+// recorded fire is before their nominal time, fire at their nominal time.
+//
+// Time doesn't move by itself between readings. Use Advance or StartAutoAdvance to fire timers.
+func NewReplay(events []RecordedEvent) *MockTime {
+	start := time.Now()
+	if len(events) > 0 {
+		start = events[0].Time
+	}
+	r := &replayer{
+		timers: make(map[int64]*replayTimer),
+	}
+	for _, e := range events {
+		switch e.Kind {
+		case EventNow:
+			r.readings = append(r.readings, e.Time)
+		case EventTimer, EventAfterFunc, EventSleep:
+			r.timers[e.ID] = &replayTimer{}
+		case EventTicker:
+			r.timers[e.ID] = &replayTimer{ticker: true}
+		case EventFire:
+			if t, ok := r.timers[e.ID]; ok {
+				t.fires = append(t.fires, e.Time)
+			}
+		}
+	}
+	m := NewMockWith(start)
+	m.replay = r
+	return m
+}
+
+// delay returns the delay of timer's next fire to match the recording.
+//
+// A timer never fires before its nominal time. If the recorded fire is earlier
+// (the run diverged from the recording, e.g. the timer was reset differently), it returns 0.
+func (r *replayer) delay(timer *MockTimer) time.Duration {
+	t, ok := r.timers[timer.id]
+	if !ok || t.ticker == timer.oneshot || len(t.fires) == 0 {
+		return 0
+	}
+	if d := t.fires[0].Sub(timer.next); d > 0 {
+		return d
+	}
+	return 0
+}
+
+func (r *replayer) popFire(id int64) {
+	if t, ok := r.timers[id]; ok && len(t.fires) > 0 {
+		t.fires = t.fires[1:]
+	}
+}
+
+// replayReading returns the next recorded reading and moves current time to it.
+func (m *MockTime) replayReading() (time.Time, bool) {
+	m.lock.Lock()
+	if m.replay == nil || len(m.replay.readings) == 0 {
+		m.lock.Unlock()
+		return time.Time{}, false
+	}
+	t := m.replay.readings[0]
+	m.replay.readings = m.replay.readings[1:]
+	m.activity++
+	m.lock.Unlock()
+
+	m.advanceTo(t, true)
+	return t, true
+}
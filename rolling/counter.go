@@ -0,0 +1,70 @@
+package rolling
+
+import (
+	"time"
+)
+
+// Counter counts events in the sliding window.
+type Counter struct {
+	r *ring[int64]
+}
+
+// NewCounter creates Counter.
+func NewCounter(opt Option) *Counter {
+	return &Counter{r: newRing[int64](opt)}
+}
+
+// Incr counts an event now.
+func (c *Counter) Incr() {
+	c.Add(1)
+}
+
+// Add adds delta to the bucket of now.
+func (c *Counter) Add(delta int64) {
+	now := c.r.time.Now()
+	c.r.lock.Lock()
+	defer c.r.lock.Unlock()
+	*c.r.current(now) += delta
+}
+
+// Sum returns the total count in the window.
+func (c *Counter) Sum() int64 {
+	now := c.r.time.Now()
+	c.r.lock.Lock()
+	defer c.r.lock.Unlock()
+	var sum int64
+	c.r.each(now, func(start time.Time, value *int64) {
+		sum += *value
+	})
+	return sum
+}
+
+// Rate returns the count per second in the window. It divides by the whole window even before the window is filled.
+func (c *Counter) Rate() float64 {
+	return float64(c.Sum()) / c.r.window.Seconds()
+}
+
+// Buckets returns counts of buckets from the oldest to the newest.
+func (c *Counter) Buckets() []int64 {
+	now := c.r.time.Now()
+	c.r.lock.Lock()
+	defer c.r.lock.Unlock()
+	result := make([]int64, len(c.r.buckets))
+	first := c.r.epoch(now) - int64(len(c.r.buckets)) + 1
+	c.r.each(now, func(start time.Time, value *int64) {
+		result[c.r.epoch(start)-first] = *value
+	})
+	return result
+}
+
+// Reset clears all buckets.
+func (c *Counter) Reset() {
+	c.r.lock.Lock()
+	defer c.r.lock.Unlock()
+	c.r.reset()
+}
+
+// Close stops the ticker of Rotate option.
+func (c *Counter) Close() {
+	c.r.close()
+}
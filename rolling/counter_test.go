@@ -0,0 +1,120 @@
+package rolling
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounter(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	c := NewCounter(Option{Time: mock, Window: 10 * time.Second, Buckets: 10})
+	defer c.Close()
+
+	for i := 0; i < 10; i++ {
+		c.Add(int64(i))
+		mock.Advance(time.Second, true)
+	}
+	// 0 + 1 + ... + 9, the first bucket just left the window
+	assert.Equal(t, int64(45), c.Sum())
+	assert.Equal(t, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 0}, c.Buckets())
+	assert.Equal(t, 4.5, c.Rate())
+
+	mock.Advance(5*time.Second, true)
+	assert.Equal(t, int64(6+7+8+9), c.Sum())
+
+	// idle longer than the window
+	mock.Advance(time.Hour, true)
+	assert.Equal(t, int64(0), c.Sum())
+	c.Incr()
+	assert.Equal(t, int64(1), c.Sum())
+	c.Reset()
+	assert.Equal(t, int64(0), c.Sum())
+}
+
+func TestCounter_Rewind(t *testing.T) {
+	start := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	mock := itime.NewMockWith(start)
+	defer mock.Close()
+	c := NewCounter(Option{Time: mock, Window: 10 * time.Second, Buckets: 10})
+
+	c.Add(5)
+	mock.Set(start.Add(-500*time.Millisecond), true)
+	c.Add(3)
+	// events after the rewound clock are out of the window
+	assert.Equal(t, int64(3), c.Sum())
+	mock.Set(start, true)
+	assert.Equal(t, int64(8), c.Sum())
+	mock.Set(start.Add(-20*time.Second), true)
+	assert.Equal(t, int64(0), c.Sum())
+}
+
+func TestCounter_Rotate(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+
+	var lock sync.Mutex
+	var rotated []time.Time
+	start := mock.Now()
+	c := NewCounter(Option{
+		Time:    mock,
+		Window:  3 * time.Second,
+		Buckets: 3,
+		Rotate:  true,
+		OnRotate: func(t time.Time) {
+			lock.Lock()
+			defer lock.Unlock()
+			rotated = append(rotated, t)
+		},
+	})
+	defer c.Close()
+
+	c.Add(10)
+	for i := 0; i < 3; i++ {
+		mock.Advance(time.Second, true)
+		for j := 0; j < 100; j++ {
+			lock.Lock()
+			n := len(rotated)
+			lock.Unlock()
+			if n > i {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	lock.Lock()
+	assert.Equal(t, []time.Time{start.Add(time.Second), start.Add(2 * time.Second), start.Add(3 * time.Second)}, rotated)
+	lock.Unlock()
+	assert.Equal(t, int64(0), c.Sum())
+}
+
+func TestCounter_NarrowWindow(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	// more buckets than nanoseconds in the window
+	c := NewCounter(Option{Time: mock, Window: 5 * time.Nanosecond, Buckets: 10, Rotate: true})
+	defer c.Close()
+
+	c.Incr()
+	mock.Advance(time.Nanosecond, true)
+	c.Incr()
+	assert.Equal(t, int64(2), c.Sum())
+	assert.Equal(t, 5, len(c.Buckets()))
+	mock.Advance(5*time.Nanosecond, true)
+	assert.Equal(t, int64(0), c.Sum())
+}
+
+func TestCounter_RateOfRoundedWindow(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	// buckets are 3.333333333s wide, so the window is 9.999999999s
+	c := NewCounter(Option{Time: mock, Window: 10 * time.Second, Buckets: 3})
+	defer c.Close()
+
+	c.Add(9999999999)
+	assert.Equal(t, 1e9, c.Rate())
+}
@@ -0,0 +1,118 @@
+package rolling
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Histogram records values in the sliding window and answers percentiles of them.
+//
+// It keeps all values in the window to answer exact percentiles.
+type Histogram struct {
+	r *ring[[]float64]
+}
+
+// NewHistogram creates Histogram.
+func NewHistogram(opt Option) *Histogram {
+	return &Histogram{r: newRing[[]float64](opt)}
+}
+
+// Observe records v now.
+func (h *Histogram) Observe(v float64) {
+	now := h.r.time.Now()
+	h.r.lock.Lock()
+	defer h.r.lock.Unlock()
+	b := h.r.current(now)
+	*b = append(*b, v)
+}
+
+// ObserveDuration records d in seconds.
+func (h *Histogram) ObserveDuration(d time.Duration) {
+	h.Observe(d.Seconds())
+}
+
+// values returns sorted values in the window.
+func (h *Histogram) values() []float64 {
+	now := h.r.time.Now()
+	h.r.lock.Lock()
+	var values []float64
+	h.r.each(now, func(start time.Time, b *[]float64) {
+		values = append(values, *b...)
+	})
+	h.r.lock.Unlock()
+	sort.Float64s(values)
+	return values
+}
+
+// Count returns the number of values in the window.
+func (h *Histogram) Count() int {
+	now := h.r.time.Now()
+	h.r.lock.Lock()
+	defer h.r.lock.Unlock()
+	count := 0
+	h.r.each(now, func(start time.Time, b *[]float64) {
+		count += len(*b)
+	})
+	return count
+}
+
+// Rate returns the number of values per second in the window.
+func (h *Histogram) Rate() float64 {
+	return float64(h.Count()) / h.r.window.Seconds()
+}
+
+// Mean returns the average of values in the window. It returns NaN if the window is empty.
+func (h *Histogram) Mean() float64 {
+	values := h.values()
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// Percentile returns the p-th (0 < p <= 100) percentile of values in the window by nearest-rank method.
+// It returns NaN if the window is empty.
+func (h *Histogram) Percentile(p float64) float64 {
+	return percentile(h.values(), p)
+}
+
+// Percentiles returns percentiles for each of ps at once.
+func (h *Histogram) Percentiles(ps ...float64) []float64 {
+	values := h.values()
+	result := make([]float64, len(ps))
+	for i, p := range ps {
+		result[i] = percentile(values, p)
+	}
+	return result
+}
+
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+// Reset clears all buckets.
+func (h *Histogram) Reset() {
+	h.r.lock.Lock()
+	defer h.r.lock.Unlock()
+	h.r.reset()
+}
+
+// Close stops the ticker of Rotate option.
+func (h *Histogram) Close() {
+	h.r.close()
+}
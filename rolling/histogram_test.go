@@ -0,0 +1,38 @@
+package rolling
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogram(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	h := NewHistogram(Option{Time: mock, Window: 10 * time.Second, Buckets: 5})
+	defer h.Close()
+
+	assert.True(t, math.IsNaN(h.Percentile(50)))
+	assert.True(t, math.IsNaN(h.Mean()))
+
+	for i := 1; i <= 100; i++ {
+		h.Observe(float64(i))
+	}
+	assert.Equal(t, 100, h.Count())
+	assert.Equal(t, 10.0, h.Rate())
+	assert.Equal(t, 50.5, h.Mean())
+	assert.Equal(t, []float64{1, 50, 90, 99, 100}, h.Percentiles(0, 50, 90, 99, 100))
+
+	// older values leave the window
+	mock.Advance(5*time.Second, true)
+	h.ObserveDuration(1500 * time.Millisecond)
+	mock.Advance(5*time.Second, true)
+	assert.Equal(t, 1, h.Count())
+	assert.Equal(t, 1.5, h.Percentile(99))
+
+	h.Reset()
+	assert.Equal(t, 0, h.Count())
+}
@@ -0,0 +1,166 @@
+// Package rolling provides counters and histograms over a sliding time window.
+//
+// Events are bucketed by itime.Time.Now(), so windows can be exercised deterministically with itime.MockTime.
+package rolling
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/shibukawa/itime"
+)
+
+// Option is an option for NewCounter and NewHistogram.
+type Option struct {
+	// Time is a clock to bucket events. Default is itime.New().
+	Time itime.Time
+	// Window is the length of the window. Default is one minute.
+	// It is rounded down to a multiple of the bucket width.
+	Window time.Duration
+	// Buckets is the number of buckets in the window. Default is 60.
+	// It is reduced to Window in nanoseconds if it is more than that.
+	Buckets int
+	// Rotate clears expired buckets by a ticker of Time every bucket width.
+	// Otherwise they are cleared lazily when the window is accessed. Results are the same,
+	// but Rotate releases memory of idle windows and calls OnRotate.
+	Rotate bool
+	// OnRotate is called with the start time of the new bucket when a ticker rotates buckets.
+	OnRotate func(t time.Time)
+}
+
+// invalidEpoch marks buckets that have no value.
+const invalidEpoch = math.MinInt64
+
+type bucket[T any] struct {
+	epoch int64
+	value T
+}
+
+// ring is a ring buffer of buckets. Each bucket remembers its epoch (index since origin)
+// to detect stale values.
+type ring[T any] struct {
+	time     itime.Time
+	origin   time.Time
+	width    time.Duration
+	window   time.Duration
+	onRotate func(t time.Time)
+
+	lock    sync.Mutex
+	buckets []bucket[T]
+	ticker  itime.Ticker
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newRing[T any](opt Option) *ring[T] {
+	if opt.Time == nil {
+		opt.Time = itime.New()
+	}
+	if opt.Window <= 0 {
+		opt.Window = time.Minute
+	}
+	if opt.Buckets <= 0 {
+		opt.Buckets = 60
+	}
+	if time.Duration(opt.Buckets) > opt.Window {
+		// a bucket can't be narrower than a nanosecond
+		opt.Buckets = int(opt.Window)
+	}
+	width := opt.Window / time.Duration(opt.Buckets)
+	r := &ring[T]{
+		time:     opt.Time,
+		origin:   opt.Time.Now(),
+		width:    width,
+		window:   width * time.Duration(opt.Buckets),
+		onRotate: opt.OnRotate,
+		buckets:  make([]bucket[T], opt.Buckets),
+		done:     make(chan struct{}),
+	}
+	r.reset()
+	if opt.Rotate {
+		r.ticker = opt.Time.NewTicker(r.width)
+		go r.run()
+	}
+	return r
+}
+
+func (r *ring[T]) run() {
+	for {
+		select {
+		case now := <-r.ticker.Chan():
+			r.lock.Lock()
+			r.expire(r.epoch(now))
+			r.lock.Unlock()
+			if r.onRotate != nil {
+				r.onRotate(r.origin.Add(time.Duration(r.epoch(now)) * r.width))
+			}
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// close stops the ticker.
+func (r *ring[T]) close() {
+	r.once.Do(func() {
+		if r.ticker != nil {
+			r.ticker.Stop()
+			close(r.done)
+		}
+	})
+}
+
+// epoch returns the index of bucket since origin.
+func (r *ring[T]) epoch(now time.Time) int64 {
+	d := now.Sub(r.origin)
+	e := int64(d / r.width)
+	if d < 0 && d%r.width != 0 {
+		e--
+	}
+	return e
+}
+
+// current returns the bucket of now. Caller must hold r.lock.
+func (r *ring[T]) current(now time.Time) *T {
+	e := r.epoch(now)
+	n := int64(len(r.buckets))
+	b := &r.buckets[((e%n)+n)%n]
+	if b.epoch != e {
+		var zero T
+		b.epoch = e
+		b.value = zero
+	}
+	return &b.value
+}
+
+// expire clears buckets outside of the window that ends at epoch e. Caller must hold r.lock.
+func (r *ring[T]) expire(e int64) {
+	n := int64(len(r.buckets))
+	for i := range r.buckets {
+		if epoch := r.buckets[i].epoch; epoch != invalidEpoch && (epoch <= e-n || epoch > e) {
+			var zero T
+			r.buckets[i] = bucket[T]{epoch: invalidEpoch, value: zero}
+		}
+	}
+}
+
+// reset clears all buckets. Caller must hold r.lock.
+func (r *ring[T]) reset() {
+	var zero T
+	for i := range r.buckets {
+		r.buckets[i] = bucket[T]{epoch: invalidEpoch, value: zero}
+	}
+}
+
+// each calls f for buckets in the window that ends at now, from the oldest. Caller must hold r.lock.
+func (r *ring[T]) each(now time.Time, f func(start time.Time, value *T)) {
+	e := r.epoch(now)
+	n := int64(len(r.buckets))
+	for epoch := e - n + 1; epoch <= e; epoch++ {
+		b := &r.buckets[((epoch%n)+n)%n]
+		if b.epoch == epoch {
+			f(r.origin.Add(time.Duration(epoch)*r.width), &b.value)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package itime
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ScaledTime is an implementation of Time interface whose time flows continuously
+// at a configured rate relative to the wall clock.
+//
+// For example, rate 60 makes one virtual minute pass in one real second,
+// and rate 0.5 makes virtual time twice as slow as real time.
+// Timers, tickers, Sleep and contexts are all scaled accordingly.
+//
+// The rate can be changed and the clock can be paused at runtime.
+type ScaledTime struct {
+	mock *MockTime
+
+	lock        sync.Mutex
+	realBase    time.Time
+	virtualBase time.Time
+	rate        float64
+	paused      bool
+
+	wake      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewScaled returns ScaledTime instance that starts from current wall clock time.
+func NewScaled(rate float64) *ScaledTime {
+	return NewScaledWith(time.Now(), rate)
+}
+
+// NewScaledWith returns ScaledTime instance that starts from t.
+func NewScaledWith(t time.Time, rate float64) *ScaledTime {
+	if rate < 0 {
+		panic("negative rate for ScaledTime")
+	}
+	s := &ScaledTime{
+		mock:        NewMockWith(t),
+		realBase:    time.Now(),
+		virtualBase: t,
+		rate:        rate,
+		wake:        make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Now returns current virtual time.
+func (s *ScaledTime) Now() time.Time {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.now(time.Now())
+}
+
+// now converts real time to virtual time.
+//
+// Caller must hold s.lock.
+func (s *ScaledTime) now(realNow time.Time) time.Time {
+	if s.paused {
+		return s.virtualBase
+	}
+	elapsed := realNow.Sub(s.realBase)
+	return s.virtualBase.Add(time.Duration(float64(elapsed) * s.rate))
+}
+
+// Rate returns current rate of virtual time against wall clock.
+func (s *ScaledTime) Rate() float64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.rate
+}
+
+// SetRate changes the rate of virtual time against wall clock.
+//
+// Virtual time elapsed so far is kept. Pending timers are rescheduled with the new rate.
+func (s *ScaledTime) SetRate(rate float64) {
+	if rate < 0 {
+		panic("negative rate for ScaledTime")
+	}
+	s.lock.Lock()
+	s.rebase()
+	s.rate = rate
+	s.lock.Unlock()
+	s.notify()
+}
+
+// Pause stops the flow of virtual time. Timers don't fire until Resume is called.
+func (s *ScaledTime) Pause() {
+	s.lock.Lock()
+	s.rebase()
+	s.paused = true
+	s.lock.Unlock()
+	s.notify()
+}
+
+// Resume restarts the flow of virtual time stopped by Pause.
+func (s *ScaledTime) Resume() {
+	s.lock.Lock()
+	s.rebase()
+	s.paused = false
+	s.lock.Unlock()
+	s.notify()
+}
+
+// rebase moves base points to current time.
+//
+// Caller must hold s.lock.
+func (s *ScaledTime) rebase() {
+	realNow := time.Now()
+	s.virtualBase = s.now(realNow)
+	s.realBase = realNow
+}
+
+// realDuration converts virtual duration to real duration.
+// It returns false if virtual time doesn't flow.
+func (s *ScaledTime) realDuration(d time.Duration) (time.Duration, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.paused || s.rate == 0 {
+		return 0, false
+	}
+	if d < 0 {
+		return 0, true
+	}
+	return time.Duration(float64(d) / s.rate), true
+}
+
+// run fires timers of the internal MockTime when virtual time reaches their deadline.
+func (s *ScaledTime) run() {
+	for {
+		s.mock.advanceTo(s.Now(), true)
+		var timer *time.Timer
+		var timerChan <-chan time.Time
+		if next, ok := s.mock.nextDeadline(); ok {
+			if d, ok := s.realDuration(next.Sub(s.Now())); ok {
+				timer = time.NewTimer(d)
+				timerChan = timer.C
+			}
+		}
+		select {
+		case <-timerChan:
+		case <-s.wake:
+		case <-s.done:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+	}
+}
+
+// sync moves the internal MockTime to current virtual time.
+//
+// It doesn't fire expired timers because callers may hold their own locks
+// that timer callbacks acquire. Only run fires timers; sync wakes it up instead.
+func (s *ScaledTime) sync() {
+	s.mock.moveTo(s.Now())
+	s.notify()
+}
+
+// notify wakes up the goroutine that fires timers to reschedule.
+func (s *ScaledTime) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops all internal timers.
+func (s *ScaledTime) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	s.mock.Close()
+}
+
+// NewTimer creates new oneshot timer.
+func (s *ScaledTime) NewTimer(d time.Duration) Timer {
+	s.sync()
+	defer s.notify()
+	return &scaledTimer{s: s, t: s.mock.NewTimer(d)}
+}
+
+// NewTicker creates interval timer.
+func (s *ScaledTime) NewTicker(d time.Duration) Ticker {
+	s.sync()
+	defer s.notify()
+	return s.mock.NewTicker(d)
+}
+
+// AfterFunc waits for the duration to elapse and then calls f.
+func (s *ScaledTime) AfterFunc(d time.Duration, f func()) Timer {
+	s.sync()
+	defer s.notify()
+	return &scaledTimer{s: s, t: s.mock.AfterFunc(d, f)}
+}
+
+// After is a shorthand of creating Timer instance.
+func (s *ScaledTime) After(d time.Duration) <-chan time.Time {
+	return s.NewTimer(d).Chan()
+}
+
+// Tick is a shorthand of creating Ticker instance.
+func (s *ScaledTime) Tick(d time.Duration) <-chan time.Time {
+	return s.NewTicker(d).Chan()
+}
+
+// Sleep waits for the virtual duration to elapse.
+func (s *ScaledTime) Sleep(d time.Duration) {
+	t := s.NewTimer(d)
+	defer t.Stop()
+	<-t.Chan()
+}
+
+// NewTimerAt creates new oneshot timer that fires when the virtual clock reaches t.
+func (s *ScaledTime) NewTimerAt(t time.Time) Timer {
+	s.sync()
+	defer s.notify()
+	return &scaledTimer{s: s, t: s.mock.NewTimerAt(t)}
+}
+
+// AfterFuncAt calls f when the virtual clock reaches t.
+func (s *ScaledTime) AfterFuncAt(t time.Time, f func()) Timer {
+	s.sync()
+	defer s.notify()
+	return &scaledTimer{s: s, t: s.mock.AfterFuncAt(t, f)}
+}
+
+// SleepUntil waits for the virtual clock to reach t.
+func (s *ScaledTime) SleepUntil(t time.Time) {
+	timer := s.NewTimerAt(t)
+	defer timer.Stop()
+	<-timer.Chan()
+}
+
+func (s *ScaledTime) WithCancel(ctx context.Context) (context.Context, context.CancelFunc) {
+	return s.mock.WithCancel(ctx)
+}
+
+func (s *ScaledTime) WithCancelCause(ctx context.Context) (context.Context, context.CancelCauseFunc) {
+	return s.mock.WithCancelCause(ctx)
+}
+
+func (s *ScaledTime) WithDeadline(ctx context.Context, d time.Time) (context.Context, context.CancelFunc) {
+	return s.WithDeadlineCause(ctx, d, nil)
+}
+
+func (s *ScaledTime) WithDeadlineCause(ctx context.Context, d time.Time, cause error) (context.Context, context.CancelFunc) {
+	s.sync()
+	defer s.notify()
+	return s.mock.WithDeadlineCause(ctx, d, cause)
+}
+
+func (s *ScaledTime) WithTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	return s.WithDeadlineCause(ctx, s.Now().Add(d), nil)
+}
+
+func (s *ScaledTime) WithTimeoutCause(ctx context.Context, d time.Duration, cause error) (context.Context, context.CancelFunc) {
+	return s.WithDeadlineCause(ctx, s.Now().Add(d), cause)
+}
+
+func (s *ScaledTime) ContextAfterFunc(ctx context.Context, f func()) (stop func() bool) {
+	return s.mock.ContextAfterFunc(ctx, f)
+}
+
+var _ Time = &ScaledTime{}
+
+// scaledTimer is a Timer of ScaledTime. It reschedules the clock when it is reset.
+type scaledTimer struct {
+	s *ScaledTime
+	t Timer
+}
+
+func (t *scaledTimer) Reset(d time.Duration) bool {
+	t.s.sync()
+	defer t.s.notify()
+	return t.t.Reset(d)
+}
+
+func (t *scaledTimer) Stop() bool {
+	return t.t.Stop()
+}
+
+func (t *scaledTimer) Chan() <-chan time.Time {
+	return t.t.Chan()
+}
+
+var _ Timer = &scaledTimer{}
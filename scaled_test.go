@@ -0,0 +1,122 @@
+package itime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaledTime_Now(t *testing.T) {
+	start := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	st := NewScaledWith(start, 1000)
+	defer st.Close()
+
+	time.Sleep(10 * time.Millisecond)
+	elapsed := st.Now().Sub(start)
+	t.Logf("elapsed: %v", elapsed)
+	assert.True(t, elapsed >= 10*time.Second)
+	assert.True(t, elapsed < time.Minute)
+}
+
+func TestScaledTime_Sleep(t *testing.T) {
+	st := NewScaled(3600)
+	defer st.Close()
+
+	realStart := time.Now()
+	virtualStart := st.Now()
+	// one virtual hour takes one real second
+	st.Sleep(10 * time.Second)
+	realElapsed := time.Since(realStart)
+	virtualElapsed := st.Now().Sub(virtualStart)
+
+	t.Logf("real: %v, virtual: %v", realElapsed, virtualElapsed)
+	assert.True(t, virtualElapsed >= 10*time.Second)
+	assert.True(t, realElapsed < time.Second)
+}
+
+func TestScaledTime_Ticker(t *testing.T) {
+	st := NewScaled(1000)
+	defer st.Close()
+
+	ticker := st.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	prev := <-ticker.Chan()
+	for i := 0; i < 5; i++ {
+		now := <-ticker.Chan()
+		assert.Equal(t, time.Second, now.Sub(prev))
+		prev = now
+	}
+}
+
+func TestScaledTime_WithTimeout(t *testing.T) {
+	st := NewScaled(1000)
+	defer st.Close()
+
+	ctx, cancel := st.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	<-ctx.Done()
+	assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestScaledTime_Pause(t *testing.T) {
+	st := NewScaled(1000)
+	defer st.Close()
+
+	fired := make(chan struct{})
+	st.AfterFunc(time.Second, func() {
+		close(fired)
+	})
+	st.Pause()
+	paused := st.Now()
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, paused, st.Now())
+
+	select {
+	case <-fired:
+		t.Fatal("timer should not fire while paused")
+	default:
+	}
+
+	st.Resume()
+	<-fired
+	assert.True(t, st.Now().Sub(paused) >= time.Second)
+}
+
+func TestScaledTime_SetRate(t *testing.T) {
+	st := NewScaled(1)
+	defer st.Close()
+
+	timer := st.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	// It takes one real hour with rate 1. Speed up to finish quickly.
+	st.SetRate(3600 * 100)
+	assert.Equal(t, float64(3600*100), st.Rate())
+
+	select {
+	case <-timer.Chan():
+	case <-time.After(time.Second):
+		t.Fatal("timer should fire after rate is changed")
+	}
+}
+
+func TestScaledTime_Reset(t *testing.T) {
+	st := NewScaled(1000)
+	defer st.Close()
+
+	timer := st.NewTimer(time.Hour)
+	defer timer.Stop()
+	assert.True(t, timer.Reset(time.Second))
+
+	select {
+	case <-timer.Chan():
+	case <-time.After(time.Second):
+		t.Fatal("timer should fire after reset")
+	}
+}
@@ -31,12 +31,14 @@ func NewSequence(opt Option) *Sequence {
 		time:            opt.Time,
 		verbose:         opt.Verbose,
 		scenarioTimeout: opt.ScenarioTimeout,
-		current:         opt.Time.Now(),
+		current:         opt.Time.now(),
 	}
 }
 
 func (s *Sequence) Wait(d time.Duration) *Sequence {
+	step := int64(len(s.sequence) + 1)
 	s.sequence = append(s.sequence, func() {
+		s.time.obs.emit(EventSequenceWait, step, s.time.now(), d)
 		s.time.Advance(d, true)
 	})
 	s.current = s.current.Add(d)
@@ -49,7 +51,9 @@ func (s *Sequence) Timeout(ctx *context.Context) *Sequence {
 }
 
 func (s *Sequence) Event(callback func()) *Sequence {
+	step := int64(len(s.sequence) + 1)
 	s.sequence = append(s.sequence, func() {
+		s.time.obs.emit(EventSequenceEvent, step, s.time.now(), 0)
 		callback()
 	})
 	return s
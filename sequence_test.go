@@ -14,14 +14,19 @@ func TestSequence_Event(t *testing.T) {
 	r, w := io.Pipe()
 
 	mt := NewMock()
+	read := observed(mt, EventNow)
 
 	err := NewSequence(Option{
 		Time: mt,
 	}).
+		Event(func() {
+			<-read
+		}).
 		Wait(2 * time.Second).
 		Event(func() {
 			io.WriteString(w, "Hello World")
 			w.Close()
+			<-read
 		}).
 		Wait(2 * time.Second).
 		Do(func() {
@@ -41,10 +46,14 @@ func TestMockSequence(t *testing.T) {
 	defer mt.Close()
 
 	var ctx context.Context
+	started := observed(mt, EventSleep)
 
 	err := NewSequence(Option{
 		Time: mt,
 	}).
+		Event(func() {
+			<-started
+		}).
 		Wait(time.Second).
 		Timeout(&ctx).
 		Wait(2 * time.Second).
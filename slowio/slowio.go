@@ -0,0 +1,150 @@
+// Package slowio provides io.Reader, io.Writer and net.Conn wrappers that simulate latency and bandwidth
+// by sleeping on itime.Time.
+//
+// With itime.MockTime in auto advance mode, a transfer of 10 MB at 1 MB/s finishes instantly in real time
+// while the virtual time advances 10 seconds.
+package slowio
+
+import (
+	"io"
+	"net"
+	"time"
+
+	"github.com/shibukawa/itime"
+)
+
+// DefaultChunkSize is the default maximum size of a single transfer.
+const DefaultChunkSize = 32 * 1024
+
+// Option is an option for wrappers.
+type Option struct {
+	// Time is a clock to sleep on. Default is itime.New().
+	Time itime.Time
+	// Latency is added to each Read and Write call.
+	Latency time.Duration
+	// BytesPerSecond limits the throughput. Zero means unlimited.
+	BytesPerSecond int64
+	// ChunkSize is the maximum number of bytes transferred by one sleep, so that progress is observed
+	// in steps of it. Default is DefaultChunkSize.
+	ChunkSize int
+}
+
+// link sleeps for latency and transfer time.
+type link struct {
+	time      itime.Time
+	latency   time.Duration
+	bps       int64
+	chunkSize int
+}
+
+func newLink(opt Option) *link {
+	if opt.Time == nil {
+		opt.Time = itime.New()
+	}
+	if opt.ChunkSize <= 0 {
+		opt.ChunkSize = DefaultChunkSize
+	}
+	return &link{
+		time:      opt.Time,
+		latency:   opt.Latency,
+		bps:       opt.BytesPerSecond,
+		chunkSize: opt.ChunkSize,
+	}
+}
+
+// transferTime returns the time to transfer n bytes.
+func (l *link) transferTime(n int) time.Duration {
+	if l.bps <= 0 {
+		return 0
+	}
+	return time.Duration(int64(n) * int64(time.Second) / l.bps)
+}
+
+func (l *link) sleep(d time.Duration) {
+	if d > 0 {
+		l.time.Sleep(d)
+	}
+}
+
+// read reads at most one chunk and then sleeps for its latency and transfer time.
+func (l *link) read(r io.Reader, p []byte) (int, error) {
+	if len(p) > l.chunkSize {
+		p = p[:l.chunkSize]
+	}
+	n, err := r.Read(p)
+	l.sleep(l.latency + l.transferTime(n))
+	return n, err
+}
+
+// write sleeps for the latency and transfer time of each chunk before writing it.
+func (l *link) write(w io.Writer, p []byte) (int, error) {
+	delay := l.latency
+	written := 0
+	for {
+		chunk := p[written:]
+		if len(chunk) > l.chunkSize {
+			chunk = chunk[:l.chunkSize]
+		}
+		l.sleep(delay + l.transferTime(len(chunk)))
+		delay = 0
+		n, err := w.Write(chunk)
+		written += n
+		if err != nil || written == len(p) {
+			return written, err
+		}
+	}
+}
+
+// Reader is an io.Reader that takes time to read.
+type Reader struct {
+	r io.Reader
+	l *link
+}
+
+// NewReader wraps r. Each Read returns at most ChunkSize bytes after sleeping for Latency and their transfer time.
+func NewReader(r io.Reader, opt Option) *Reader {
+	return &Reader{r: r, l: newLink(opt)}
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	return r.l.read(r.r, p)
+}
+
+// Writer is an io.Writer that takes time to write.
+type Writer struct {
+	w io.Writer
+	l *link
+}
+
+// NewWriter wraps w. Each Write sleeps for Latency once, and for the transfer time before each chunk.
+func NewWriter(w io.Writer, opt Option) *Writer {
+	return &Writer{w: w, l: newLink(opt)}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	return w.l.write(w.w, p)
+}
+
+// Conn is a net.Conn that takes time to read and write. Both directions have their own link.
+//
+// Sleeps are not interrupted by deadlines of the connection.
+type Conn struct {
+	net.Conn
+	read  *link
+	write *link
+}
+
+var _ net.Conn = &Conn{}
+
+// NewConn wraps conn with the same option for both directions.
+func NewConn(conn net.Conn, opt Option) *Conn {
+	return &Conn{Conn: conn, read: newLink(opt), write: newLink(opt)}
+}
+
+func (c *Conn) Read(p []byte) (int, error) {
+	return c.read.read(c.Conn, p)
+}
+
+func (c *Conn) Write(p []byte) (int, error) {
+	return c.write.write(c.Conn, p)
+}
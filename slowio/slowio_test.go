@@ -0,0 +1,128 @@
+package slowio
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+// sleeping returns a channel that receives when a goroutine starts sleeping on mock.
+func sleeping(mock *itime.MockTime) <-chan struct{} {
+	c := make(chan struct{}, 1)
+	mock.AddObserver(itime.ObserverFunc(func(e itime.ObservedEvent) {
+		if e.Kind == itime.EventSleep {
+			select {
+			case c <- struct{}{}:
+			default:
+			}
+		}
+	}))
+	return c
+}
+
+func TestReader_Bandwidth(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	mock.StartAutoAdvance(itime.AutoAdvanceOption{})
+	start := mock.Now()
+
+	const size = 10 * 1000 * 1000
+	r := NewReader(bytes.NewReader(make([]byte, size)), Option{Time: mock, BytesPerSecond: 1000 * 1000, ChunkSize: 1000 * 1000})
+	realStart := time.Now()
+	// hide io.Discard's ReaderFrom so that the buffer size is used
+	n, err := io.CopyBuffer(struct{ io.Writer }{io.Discard}, r, make([]byte, 1000*1000))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(size), n)
+	assert.Equal(t, 10*time.Second, mock.Now().Sub(start))
+	assert.True(t, time.Since(realStart) < 5*time.Second)
+}
+
+func TestReader_Latency(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	start := mock.Now()
+
+	r := NewReader(bytes.NewReader([]byte("hello")), Option{Time: mock, Latency: 100 * time.Millisecond, BytesPerSecond: 50})
+	started := sleeping(mock)
+	result := make(chan time.Duration)
+	go func() {
+		buf := make([]byte, 10)
+		n, _ := r.Read(buf)
+		assert.Equal(t, "hello", string(buf[:n]))
+		result <- mock.Now().Sub(start)
+	}()
+	err := itime.NewSequence(itime.Option{Time: mock}).
+		Event(func() {
+			<-started
+		}).
+		Wait(199 * time.Millisecond).
+		Event(func() {
+			select {
+			case <-result:
+				t.Error("read finished too early")
+			default:
+			}
+		}).
+		Wait(time.Millisecond).
+		Do(func() {
+			// 100ms latency + 5 bytes at 50 B/s
+			assert.Equal(t, 200*time.Millisecond, <-result)
+		})
+	assert.NoError(t, err)
+}
+
+func TestWriter_Chunks(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	mock.StartAutoAdvance(itime.AutoAdvanceOption{})
+	start := mock.Now()
+
+	var progress []time.Duration
+	var buf bytes.Buffer
+	w := NewWriter(writerFunc(func(p []byte) (int, error) {
+		progress = append(progress, mock.Now().Sub(start))
+		return buf.Write(p)
+	}), Option{Time: mock, Latency: time.Second, BytesPerSecond: 10, ChunkSize: 10})
+
+	n, err := w.Write(make([]byte, 25))
+	assert.NoError(t, err)
+	assert.Equal(t, 25, n)
+	assert.Equal(t, 25, buf.Len())
+	assert.Equal(t, []time.Duration{2 * time.Second, 3 * time.Second, 3500 * time.Millisecond}, progress)
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+func TestConn(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	mock.StartAutoAdvance(itime.AutoAdvanceOption{})
+	start := mock.Now()
+
+	c1, c2 := net.Pipe()
+	slow := NewConn(c1, Option{Time: mock, Latency: 50 * time.Millisecond})
+	defer slow.Close()
+	defer c2.Close()
+
+	go func() {
+		buf := make([]byte, 4)
+		io.ReadFull(c2, buf)
+		c2.Write(buf)
+	}()
+	_, err := slow.Write([]byte("ping"))
+	assert.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(slow, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "ping", string(buf))
+	assert.Equal(t, 100*time.Millisecond, mock.Now().Sub(start))
+}
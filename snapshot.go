@@ -0,0 +1,89 @@
+package itime
+
+import (
+	"time"
+)
+
+// MockSnapshot is a checkpoint of MockTime taken by MockTime.Snapshot.
+//
+// It is immutable, so it can be restored many times to fork scenarios from the same point.
+type MockSnapshot struct {
+	current time.Time
+	timers  []timerState
+}
+
+type timerState struct {
+	timer *MockTimer
+	d     time.Duration
+	next  time.Time
+	delay time.Duration
+}
+
+// Now returns the virtual time of the checkpoint.
+func (s *MockSnapshot) Now() time.Time {
+	return s.current
+}
+
+// Snapshot captures current time and the schedule of pending timers and tickers
+// (their next deadlines and intervals).
+func (m *MockTime) Snapshot() *MockSnapshot {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	s := &MockSnapshot{
+		current: m.current,
+		timers:  make([]timerState, 0, len(m.timers)),
+	}
+	for _, timer := range m.timers {
+		s.timers = append(s.timers, timerState{
+			timer: timer,
+			d:     timer.d,
+			next:  timer.next,
+			delay: timer.delay,
+		})
+	}
+	return s
+}
+
+// Restore rewinds the MockTime to the checkpoint s.
+//
+// The rules for timers are:
+//
+//   - Timers pending at the checkpoint are re-armed with the captured schedule, even if they
+//     fired, were reset or were stopped after that. They keep their channels, so goroutines
+//     waiting on them receive a value when they fire again.
+//   - Timers created after the checkpoint are stopped. Goroutines waiting on them keep
+//     blocking like waiting on a stopped time.Timer.
+//   - Values already sent to channels but not received yet are discarded because they
+//     haven't happened at the checkpoint.
+//
+// Contexts can't be restored. Cancellation of mock contexts after the checkpoint remains.
+func (m *MockTime) Restore(s *MockSnapshot) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.activity++
+
+	restored := make(map[*MockTimer]bool, len(s.timers))
+	for _, state := range s.timers {
+		restored[state.timer] = true
+	}
+	for _, timer := range m.timers {
+		if !restored[timer] {
+			timer.closed = true
+		}
+	}
+
+	m.current = s.current
+	m.timers = make([]*MockTimer, 0, len(s.timers))
+	for _, state := range s.timers {
+		timer := state.timer
+		timer.d = state.d
+		timer.next = state.next
+		timer.delay = state.delay
+		timer.closed = false
+		select {
+		case <-timer.c:
+		default:
+		}
+		m.timers = append(m.timers, timer)
+	}
+}
@@ -0,0 +1,67 @@
+package itime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockTime_Snapshot(t *testing.T) {
+	start := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	mock := NewMockWith(start)
+	defer mock.Close()
+
+	ticker := mock.NewTicker(time.Second)
+	timer := mock.NewTimer(10 * time.Second)
+	mock.Advance(1500*time.Millisecond, true)
+	<-ticker.Chan()
+
+	checkpoint := mock.Snapshot()
+	assert.Equal(t, start.Add(1500*time.Millisecond), checkpoint.Now())
+
+	for i := 0; i < 3; i++ {
+		mock.Restore(checkpoint)
+		assert.Equal(t, checkpoint.Now(), mock.Now())
+
+		// timer created after the checkpoint is stopped by the next restore
+		later := mock.NewTimer(time.Second)
+
+		mock.Advance(500*time.Millisecond, true)
+		assert.Equal(t, start.Add(2*time.Second), <-ticker.Chan())
+
+		// fork the scenario in different ways
+		if i%2 == 0 {
+			mock.Advance(10*time.Second, true)
+			assert.Equal(t, start.Add(10*time.Second), <-timer.Chan())
+		} else {
+			assert.True(t, timer.Stop())
+			mock.Advance(500*time.Millisecond, true)
+			<-later.Chan()
+		}
+		mock.Restore(checkpoint)
+		assert.False(t, later.Stop())
+	}
+}
+
+func TestMockTime_Restore_DiscardsPendingValues(t *testing.T) {
+	mock := NewMock()
+	defer mock.Close()
+
+	timer := mock.NewTimer(time.Second)
+	checkpoint := mock.Snapshot()
+
+	// fire, but not received
+	mock.Advance(time.Second, true)
+	mock.Restore(checkpoint)
+
+	select {
+	case <-timer.Chan():
+		t.Fatal("value sent after the checkpoint should be discarded")
+	default:
+	}
+
+	// the timer fires again
+	mock.Advance(time.Second, true)
+	<-timer.Chan()
+}
@@ -0,0 +1,59 @@
+package stopwatch
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Stats is summary statistics of durations.
+type Stats struct {
+	Count  int
+	Total  time.Duration
+	Min    time.Duration
+	Max    time.Duration
+	Mean   time.Duration
+	Median time.Duration
+	// StdDev is the population standard deviation.
+	StdDev time.Duration
+}
+
+// Summarize calculates statistics of durations. All fields are zero if durations is empty.
+func Summarize(durations []time.Duration) Stats {
+	if len(durations) == 0 {
+		return Stats{}
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	s := Stats{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Max:   sorted[len(sorted)-1],
+	}
+	for _, d := range sorted {
+		s.Total += d
+	}
+	s.Mean = s.Total / time.Duration(s.Count)
+	if half := s.Count / 2; s.Count%2 == 1 {
+		s.Median = sorted[half]
+	} else {
+		s.Median = sorted[half-1] + (sorted[half]-sorted[half-1])/2
+	}
+	mean := float64(s.Total) / float64(s.Count)
+	variance := 0.0
+	for _, d := range sorted {
+		diff := float64(d) - mean
+		variance += diff * diff
+	}
+	s.StdDev = time.Duration(math.Sqrt(variance / float64(s.Count)))
+	return s
+}
+
+// String formats the statistics for logs.
+func (s Stats) String() string {
+	return fmt.Sprintf("count=%d total=%v min=%v max=%v mean=%v median=%v stddev=%v",
+		s.Count, s.Total, s.Min, s.Max, s.Mean, s.Median, s.StdDev)
+}
@@ -0,0 +1,143 @@
+// Package stopwatch provides a stopwatch with laps measured by itime.Time.
+//
+// With itime.MockTime, elapsed times are exact, so they can be asserted and logged verbatim.
+package stopwatch
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shibukawa/itime"
+)
+
+// Stopwatch measures running time. Paused time is not counted.
+type Stopwatch struct {
+	time itime.Time
+
+	lock    sync.Mutex
+	running bool
+	// accumulated running time until since
+	elapsed time.Duration
+	since   time.Time
+	lastLap time.Duration
+	laps    []time.Duration
+}
+
+// New creates a stopped Stopwatch. If t is nil, itime.New() is used.
+func New(t itime.Time) *Stopwatch {
+	if t == nil {
+		t = itime.New()
+	}
+	return &Stopwatch{time: t}
+}
+
+// Start creates a Stopwatch and starts it.
+func Start(t itime.Time) *Stopwatch {
+	s := New(t)
+	s.Start()
+	return s
+}
+
+// Start clears elapsed time and laps, and starts measuring.
+func (s *Stopwatch) Start() {
+	now := s.time.Now()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.running = true
+	s.elapsed = 0
+	s.since = now
+	s.lastLap = 0
+	s.laps = nil
+}
+
+// Stop stops measuring, records the running lap if any, and returns the elapsed time.
+func (s *Stopwatch) Stop() time.Duration {
+	now := s.time.Now()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.pause(now)
+	if s.elapsed > s.lastLap {
+		s.laps = append(s.laps, s.elapsed-s.lastLap)
+		s.lastLap = s.elapsed
+	}
+	return s.elapsed
+}
+
+// Pause stops measuring without recording a lap. Resume continues it.
+func (s *Stopwatch) Pause() {
+	now := s.time.Now()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.pause(now)
+}
+
+// Resume continues measuring after Pause or Stop.
+func (s *Stopwatch) Resume() {
+	now := s.time.Now()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.running {
+		s.running = true
+		s.since = now
+	}
+}
+
+// Lap records and returns the running time since the previous lap (or start).
+func (s *Stopwatch) Lap() time.Duration {
+	now := s.time.Now()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	elapsed := s.elapsedAt(now)
+	lap := elapsed - s.lastLap
+	s.laps = append(s.laps, lap)
+	s.lastLap = elapsed
+	return lap
+}
+
+// Elapsed returns the total running time.
+func (s *Stopwatch) Elapsed() time.Duration {
+	now := s.time.Now()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.elapsedAt(now)
+}
+
+// Running returns true if the stopwatch is measuring.
+func (s *Stopwatch) Running() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.running
+}
+
+// Laps returns recorded laps.
+func (s *Stopwatch) Laps() []time.Duration {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]time.Duration(nil), s.laps...)
+}
+
+// Summary returns statistics over recorded laps.
+func (s *Stopwatch) Summary() Stats {
+	return Summarize(s.Laps())
+}
+
+// String returns the elapsed time.
+func (s *Stopwatch) String() string {
+	return s.Elapsed().String()
+}
+
+// pause accumulates running time. Caller must hold s.lock.
+func (s *Stopwatch) pause(now time.Time) {
+	if s.running {
+		s.elapsed += now.Sub(s.since)
+		s.running = false
+	}
+}
+
+// elapsedAt returns running time at now. Caller must hold s.lock.
+func (s *Stopwatch) elapsedAt(now time.Time) time.Duration {
+	if s.running {
+		return s.elapsed + now.Sub(s.since)
+	}
+	return s.elapsed
+}
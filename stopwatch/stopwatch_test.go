@@ -0,0 +1,79 @@
+package stopwatch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStopwatch(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	s := New(mock)
+	assert.False(t, s.Running())
+	assert.Equal(t, time.Duration(0), s.Elapsed())
+
+	s.Start()
+	mock.Advance(time.Second, true)
+	assert.Equal(t, time.Second, s.Lap())
+	mock.Advance(2*time.Second, true)
+	assert.Equal(t, 3*time.Second, s.Elapsed())
+	assert.Equal(t, "3s", s.String())
+
+	// paused time is not counted in elapsed time nor laps
+	s.Pause()
+	assert.False(t, s.Running())
+	mock.Advance(time.Hour, true)
+	s.Resume()
+	s.Resume()
+	mock.Advance(time.Second, true)
+	assert.Equal(t, 3*time.Second, s.Lap())
+
+	mock.Advance(500*time.Millisecond, true)
+	assert.Equal(t, 4500*time.Millisecond, s.Stop())
+	mock.Advance(time.Hour, true)
+	assert.Equal(t, 4500*time.Millisecond, s.Elapsed())
+	// stopping twice doesn't add an empty lap
+	s.Stop()
+	assert.Equal(t, []time.Duration{time.Second, 3 * time.Second, 500 * time.Millisecond}, s.Laps())
+
+	s.Start()
+	assert.Nil(t, s.Laps())
+	assert.Equal(t, time.Duration(0), s.Elapsed())
+}
+
+func TestStopwatch_Summary(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	s := Start(mock)
+	for _, d := range []time.Duration{3 * time.Second, time.Second, 2 * time.Second, 6 * time.Second} {
+		mock.Advance(d, true)
+		s.Lap()
+	}
+	stats := s.Summary()
+	assert.Equal(t, Stats{
+		Count:  4,
+		Total:  12 * time.Second,
+		Min:    time.Second,
+		Max:    6 * time.Second,
+		Mean:   3 * time.Second,
+		Median: 2500 * time.Millisecond,
+		StdDev: 1870828693,
+	}, stats)
+	assert.Equal(t, "count=4 total=12s min=1s max=6s mean=3s median=2.5s stddev=1.870828693s", stats.String())
+}
+
+func TestSummarize(t *testing.T) {
+	assert.Equal(t, Stats{}, Summarize(nil))
+	assert.Equal(t, Stats{
+		Count:  3,
+		Total:  6 * time.Second,
+		Min:    time.Second,
+		Max:    3 * time.Second,
+		Mean:   2 * time.Second,
+		Median: 2 * time.Second,
+		StdDev: 816496580,
+	}, Summarize([]time.Duration{3 * time.Second, time.Second, 2 * time.Second}))
+}
@@ -29,7 +29,28 @@ type Time interface {
 	After(d time.Duration) <-chan time.Time
 	Tick(d time.Duration) <-chan time.Time
 	Sleep(d time.Duration)
+	NewTimerAt(t time.Time) Timer
+	AfterFuncAt(t time.Time, f func()) Timer
+	SleepUntil(t time.Time)
 	Close()
+	WithCancel(context.Context) (context.Context, context.CancelFunc)
+	WithCancelCause(context.Context) (context.Context, context.CancelCauseFunc)
 	WithDeadline(context.Context, time.Time) (context.Context, context.CancelFunc)
+	WithDeadlineCause(context.Context, time.Time, error) (context.Context, context.CancelFunc)
 	WithTimeout(context.Context, time.Duration) (context.Context, context.CancelFunc)
+	WithTimeoutCause(context.Context, time.Duration, error) (context.Context, context.CancelFunc)
+	ContextAfterFunc(ctx context.Context, f func()) (stop func() bool)
+}
+
+// LocalDeadline returns the deadline of ctx on the clock t.
+//
+// Contexts created by OffsetTime report real deadlines, so they are shifted here.
+// For other clocks it is the same as ctx.Deadline().
+func LocalDeadline(ctx context.Context, t Time) (deadline time.Time, ok bool) {
+	if l, ok := t.(interface {
+		LocalDeadline(context.Context) (time.Time, bool)
+	}); ok {
+		return l.LocalDeadline(ctx)
+	}
+	return ctx.Deadline()
 }
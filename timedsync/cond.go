@@ -0,0 +1,114 @@
+// Package timedsync provides synchronization primitives with timed waits on itime.Time.
+//
+// With itime.MockTime, the timeouts fire only when the mock clock is advanced.
+package timedsync
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/shibukawa/itime"
+)
+
+// Cond is a condition variable like sync.Cond with timed waits.
+type Cond struct {
+	// L is held while observing or changing the condition.
+	L sync.Locker
+
+	time    itime.Time
+	lock    sync.Mutex
+	waiters []chan struct{}
+}
+
+// NewCond creates Cond with l. Timeouts are measured by t. If t is nil, itime.New() is used.
+func NewCond(l sync.Locker, t itime.Time) *Cond {
+	if t == nil {
+		t = itime.New()
+	}
+	return &Cond{L: l, time: t}
+}
+
+// Wait unlocks L, waits for Signal or Broadcast, and locks L again.
+func (c *Cond) Wait() {
+	ch := c.enqueue()
+	c.L.Unlock()
+	<-ch
+	c.L.Lock()
+}
+
+// WaitTimeout works like Wait, but gives up after d. It returns false on timeout.
+// L is locked again in both cases.
+func (c *Cond) WaitTimeout(d time.Duration) bool {
+	ch := c.enqueue()
+	c.L.Unlock()
+	timer := c.time.NewTimer(d)
+	ok := true
+	select {
+	case <-ch:
+		timer.Stop()
+	case <-timer.Chan():
+		ok = c.dequeue(ch)
+	}
+	c.L.Lock()
+	return ok
+}
+
+// WaitUntil works like Wait, but gives up when ctx is done and returns its error.
+// L is locked again in both cases.
+func (c *Cond) WaitUntil(ctx context.Context) error {
+	ch := c.enqueue()
+	c.L.Unlock()
+	var err error
+	select {
+	case <-ch:
+	case <-ctx.Done():
+		if !c.dequeue(ch) {
+			err = ctx.Err()
+		}
+	}
+	c.L.Lock()
+	return err
+}
+
+// Signal wakes one waiter if any.
+func (c *Cond) Signal() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.waiters) > 0 {
+		close(c.waiters[0])
+		c.waiters = c.waiters[1:]
+	}
+}
+
+// Broadcast wakes all waiters.
+func (c *Cond) Broadcast() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, ch := range c.waiters {
+		close(ch)
+	}
+	c.waiters = nil
+}
+
+func (c *Cond) enqueue() chan struct{} {
+	ch := make(chan struct{})
+	c.lock.Lock()
+	c.waiters = append(c.waiters, ch)
+	c.lock.Unlock()
+	return ch
+}
+
+// dequeue removes the waiter that gave up. It returns true if the waiter has been signaled in the meantime,
+// so the signal is not lost.
+func (c *Cond) dequeue(ch chan struct{}) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for i, w := range c.waiters {
+		if w == ch {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,144 @@
+package timedsync
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+// pending asserts that nothing is sent to ch yet.
+func pending[T any](t *testing.T, ch chan T) func() {
+	return func() {
+		select {
+		case v := <-ch:
+			t.Errorf("finished too early: %v", v)
+		default:
+		}
+	}
+}
+
+// started returns a channel that receives each time an event of kind happens on mock.
+func started(mock *itime.MockTime, kind string) <-chan struct{} {
+	c := make(chan struct{}, 16)
+	mock.AddObserver(itime.ObserverFunc(func(e itime.ObservedEvent) {
+		if e.Kind == kind {
+			c <- struct{}{}
+		}
+	}))
+	return c
+}
+
+func TestCond_WaitTimeout(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	var lock sync.Mutex
+	c := NewCond(&lock, mock)
+	timers := started(mock, itime.EventTimer)
+
+	result := make(chan bool, 1)
+	go func() {
+		lock.Lock()
+		defer lock.Unlock()
+		result <- c.WaitTimeout(time.Second)
+	}()
+	err := itime.NewSequence(itime.Option{Time: mock}).
+		Event(func() {
+			<-timers
+		}).
+		Wait(999 * time.Millisecond).
+		Event(pending(t, result)).
+		Wait(time.Millisecond).
+		Do(func() {
+			assert.False(t, <-result)
+		})
+	assert.NoError(t, err)
+}
+
+func TestCond_Signal(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	var lock sync.Mutex
+	c := NewCond(&lock, mock)
+	timers := started(mock, itime.EventTimer)
+	ready := false
+
+	result := make(chan bool, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			lock.Lock()
+			defer lock.Unlock()
+			for !ready {
+				if !c.WaitTimeout(time.Second) {
+					result <- false
+					return
+				}
+			}
+			result <- true
+		}()
+	}
+	err := itime.NewSequence(itime.Option{Time: mock}).
+		Event(func() {
+			// both waiters are queued
+			<-timers
+			<-timers
+			lock.Lock()
+			ready = true
+			lock.Unlock()
+			c.Signal()
+		}).
+		Wait(time.Second).
+		Do(func() {
+			// the other waiter is not signaled and times out
+			assert.Equal(t, []bool{true, false}, []bool{<-result, <-result})
+		})
+	assert.NoError(t, err)
+}
+
+func TestCond_Broadcast(t *testing.T) {
+	var lock sync.Mutex
+	c := NewCond(&lock, itime.NewMock())
+
+	var wg sync.WaitGroup
+	var started sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		started.Add(1)
+		go func() {
+			defer wg.Done()
+			lock.Lock()
+			started.Done()
+			c.Wait()
+			lock.Unlock()
+		}()
+	}
+	started.Wait()
+	// waiters release the lock only after they are queued
+	lock.Lock()
+	c.Broadcast()
+	lock.Unlock()
+	wg.Wait()
+}
+
+func TestCond_WaitUntil(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	var lock sync.Mutex
+	c := NewCond(&lock, mock)
+
+	var ctx context.Context
+	result := make(chan error, 1)
+	err := itime.NewSequence(itime.Option{Time: mock}).
+		Wait(time.Second).
+		Timeout(&ctx).
+		Do(func() {
+			lock.Lock()
+			defer lock.Unlock()
+			result <- c.WaitUntil(ctx)
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, context.DeadlineExceeded, <-result)
+}
@@ -0,0 +1,147 @@
+package timedsync
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/shibukawa/itime"
+)
+
+type semaphoreWaiter struct {
+	n     int64
+	ready chan struct{}
+}
+
+// Semaphore is a weighted semaphore. Waiters are served in FIFO order.
+type Semaphore struct {
+	time    itime.Time
+	size    int64
+	lock    sync.Mutex
+	cur     int64
+	waiters list.List
+}
+
+// NewSemaphore creates Semaphore with the total weight n. Timeouts are measured by t. If t is nil, itime.New() is used.
+func NewSemaphore(n int64, t itime.Time) *Semaphore {
+	if t == nil {
+		t = itime.New()
+	}
+	return &Semaphore{time: t, size: n}
+}
+
+// TryAcquire acquires n without blocking. It returns false if it can't.
+func (s *Semaphore) TryAcquire(n int64) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+// Acquire acquires n, blocking until it is available or ctx is done. It returns the error of ctx in the latter case.
+func (s *Semaphore) Acquire(ctx context.Context, n int64) error {
+	elem, ok := s.enqueue(n)
+	if ok {
+		return nil
+	}
+	if elem == nil {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	w := elem.Value.(semaphoreWaiter)
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		if s.cancel(elem) {
+			return ctx.Err()
+		}
+		return nil
+	}
+}
+
+// AcquireTimeout acquires n, blocking until it is available or d elapses. It returns false on timeout.
+func (s *Semaphore) AcquireTimeout(n int64, d time.Duration) bool {
+	elem, ok := s.enqueue(n)
+	if ok {
+		return true
+	}
+	timer := s.time.NewTimer(d)
+	defer timer.Stop()
+	if elem == nil {
+		<-timer.Chan()
+		return false
+	}
+	w := elem.Value.(semaphoreWaiter)
+	select {
+	case <-w.ready:
+		return true
+	case <-timer.Chan():
+		return !s.cancel(elem)
+	}
+}
+
+// Release releases n and wakes waiters that fit.
+func (s *Semaphore) Release(n int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.cur -= n
+	if s.cur < 0 {
+		panic("timedsync: released more than held")
+	}
+	s.notify()
+}
+
+// enqueue acquires n immediately, or queues a waiter. elem is nil if n exceeds the size and never succeeds.
+func (s *Semaphore) enqueue(n int64) (elem *list.Element, ok bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		return nil, true
+	}
+	if n > s.size {
+		return nil, false
+	}
+	return s.waiters.PushBack(semaphoreWaiter{n: n, ready: make(chan struct{})}), false
+}
+
+// cancel removes the waiter that gave up. It returns false if the waiter has acquired in the meantime.
+func (s *Semaphore) cancel(elem *list.Element) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	w := elem.Value.(semaphoreWaiter)
+	select {
+	case <-w.ready:
+		return false
+	default:
+	}
+	isFront := s.waiters.Front() == elem
+	s.waiters.Remove(elem)
+	if isFront {
+		// waiters behind the canceled one may fit now
+		s.notify()
+	}
+	return true
+}
+
+// notify wakes waiters from the front while they fit. Caller must hold s.lock.
+func (s *Semaphore) notify() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+		w := front.Value.(semaphoreWaiter)
+		if s.size-s.cur < w.n {
+			return
+		}
+		s.cur += w.n
+		s.waiters.Remove(front)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,121 @@
+package timedsync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSemaphore_AcquireTimeout(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	s := NewSemaphore(3, mock)
+
+	assert.True(t, s.TryAcquire(2))
+	assert.False(t, s.TryAcquire(2))
+	timers := started(mock, itime.EventTimer)
+
+	result := make(chan bool, 1)
+	go func() {
+		result <- s.AcquireTimeout(2, time.Second)
+	}()
+	err := itime.NewSequence(itime.Option{Time: mock}).
+		Event(func() {
+			<-timers
+		}).
+		Wait(999 * time.Millisecond).
+		Event(pending(t, result)).
+		Wait(time.Millisecond).
+		Do(func() {
+			assert.False(t, <-result)
+		})
+	assert.NoError(t, err)
+
+	go func() {
+		result <- s.AcquireTimeout(2, time.Second)
+	}()
+	err = itime.NewSequence(itime.Option{Time: mock}).
+		Wait(500 * time.Millisecond).
+		Event(func() {
+			s.Release(1)
+		}).
+		Do(func() {
+			assert.True(t, <-result)
+		})
+	assert.NoError(t, err)
+	assert.False(t, s.TryAcquire(1))
+}
+
+func TestSemaphore_FIFO(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	s := NewSemaphore(2, mock)
+	assert.True(t, s.TryAcquire(2))
+	timers := started(mock, itime.EventTimer)
+
+	order := make(chan int, 2)
+	err := itime.NewSequence(itime.Option{Time: mock}).
+		Event(func() {
+			go func() {
+				// big waiter gives up after one second
+				if s.AcquireTimeout(2, time.Second) {
+					order <- 2
+				}
+			}()
+			<-timers
+		}).
+		Event(func() {
+			go func() {
+				if s.AcquireTimeout(1, time.Minute) {
+					order <- 1
+				}
+			}()
+			<-timers
+		}).
+		Event(func() {
+			s.Release(1)
+			// the small waiter must not pass the big one
+			assert.False(t, s.TryAcquire(1))
+			pending(t, order)()
+		}).
+		Wait(time.Second).
+		Do(func() {
+			assert.Equal(t, 1, <-order)
+		})
+	assert.NoError(t, err)
+}
+
+func TestSemaphore_AcquireContext(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	s := NewSemaphore(1, mock)
+	assert.True(t, s.TryAcquire(1))
+
+	var ctx context.Context
+	err := itime.NewSequence(itime.Option{Time: mock}).
+		Wait(time.Second).
+		Timeout(&ctx).
+		Do(func() {
+			assert.Equal(t, context.DeadlineExceeded, s.Acquire(ctx, 1))
+		})
+	assert.NoError(t, err)
+	s.Release(1)
+	assert.NoError(t, s.Acquire(context.Background(), 1))
+}
+
+func TestSemaphore_TooLarge(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	s := NewSemaphore(1, mock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, s.Acquire(ctx, 2))
+	assert.NoError(t, s.Acquire(context.Background(), 1))
+	assert.Panics(t, func() {
+		s.Release(2)
+	})
+}
@@ -0,0 +1,102 @@
+package timedsync
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/shibukawa/itime"
+)
+
+// WaitGroup is sync.WaitGroup with timed waits.
+type WaitGroup struct {
+	time  itime.Time
+	lock  sync.Mutex
+	count int
+	done  chan struct{}
+}
+
+// NewWaitGroup creates WaitGroup. Timeouts are measured by t. If t is nil, itime.New() is used.
+func NewWaitGroup(t itime.Time) *WaitGroup {
+	if t == nil {
+		t = itime.New()
+	}
+	done := make(chan struct{})
+	close(done)
+	return &WaitGroup{time: t, done: done}
+}
+
+// Add adds delta to the counter. It panics if the counter becomes negative.
+func (wg *WaitGroup) Add(delta int) {
+	wg.lock.Lock()
+	defer wg.lock.Unlock()
+	count := wg.count + delta
+	switch {
+	case count < 0:
+		panic("timedsync: negative WaitGroup counter")
+	case count == 0 && wg.count > 0:
+		close(wg.done)
+	case count > 0 && wg.count == 0:
+		wg.done = make(chan struct{})
+	}
+	wg.count = count
+}
+
+// Done decrements the counter by one.
+func (wg *WaitGroup) Done() {
+	wg.Add(-1)
+}
+
+// Go calls f in a new goroutine and adds it to the group.
+func (wg *WaitGroup) Go(f func()) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		f()
+	}()
+}
+
+func (wg *WaitGroup) channel() chan struct{} {
+	wg.lock.Lock()
+	defer wg.lock.Unlock()
+	return wg.done
+}
+
+// Wait blocks until the counter is zero.
+func (wg *WaitGroup) Wait() {
+	<-wg.channel()
+}
+
+// WaitTimeout waits until the counter is zero or d elapses. It returns false on timeout.
+func (wg *WaitGroup) WaitTimeout(d time.Duration) bool {
+	done := wg.channel()
+	select {
+	case <-done:
+		return true
+	default:
+	}
+	timer := wg.time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.Chan():
+		return false
+	}
+}
+
+// WaitContext waits until the counter is zero or ctx is done, and returns the error of ctx in the latter case.
+func (wg *WaitGroup) WaitContext(ctx context.Context) error {
+	done := wg.channel()
+	select {
+	case <-done:
+		return nil
+	default:
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,69 @@
+package timedsync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shibukawa/itime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitGroup_WaitTimeout(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	wg := NewWaitGroup(mock)
+	assert.True(t, wg.WaitTimeout(time.Second))
+
+	sleeps := started(mock, itime.EventSleep)
+	timers := started(mock, itime.EventTimer)
+	wg.Go(func() {
+		mock.Sleep(1500 * time.Millisecond)
+	})
+	result := make(chan bool, 2)
+	go func() {
+		result <- wg.WaitTimeout(time.Second)
+		result <- wg.WaitTimeout(time.Second)
+	}()
+	err := itime.NewSequence(itime.Option{Time: mock}).
+		Event(func() {
+			<-sleeps
+			<-timers
+		}).
+		Wait(time.Second).
+		Event(func() {
+			assert.False(t, <-result)
+		}).
+		Wait(time.Second).
+		Do(func() {})
+	assert.NoError(t, err)
+	assert.True(t, <-result)
+	wg.Wait()
+}
+
+func TestWaitGroup_WaitContext(t *testing.T) {
+	mock := itime.NewMock()
+	defer mock.Close()
+	wg := NewWaitGroup(mock)
+	wg.Add(2)
+
+	var ctx context.Context
+	err := itime.NewSequence(itime.Option{Time: mock}).
+		Wait(time.Second).
+		Timeout(&ctx).
+		Do(func() {
+			assert.Equal(t, context.DeadlineExceeded, wg.WaitContext(ctx))
+		})
+	assert.NoError(t, err)
+
+	wg.Done()
+	wg.Done()
+	assert.NoError(t, wg.WaitContext(context.Background()))
+}
+
+func TestWaitGroup_Negative(t *testing.T) {
+	wg := NewWaitGroup(nil)
+	assert.Panics(t, func() {
+		wg.Done()
+	})
+}
@@ -0,0 +1,248 @@
+package itime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Kinds of ObservedEvent notified by Sequence
+const (
+	EventSequenceWait  = "wait"
+	EventSequenceEvent = "event"
+)
+
+// traceEvent is an event of Chrome trace event format.
+type traceEvent struct {
+	Name  string                 `json:"name"`
+	Cat   string                 `json:"cat,omitempty"`
+	Ph    string                 `json:"ph"`
+	Ts    float64                `json:"ts"`
+	Dur   *float64               `json:"dur,omitempty"`
+	Pid   int                    `json:"pid"`
+	Tid   int64                  `json:"tid"`
+	ID    string                 `json:"id,omitempty"`
+	Scope string                 `json:"s,omitempty"`
+	Args  map[string]interface{} `json:"args,omitempty"`
+}
+
+// spanKey identifies a span. IDs of timers and sleeps are unique only within a clock.
+type spanKey struct {
+	clock int64
+	id    int64
+}
+
+type traceSpan struct {
+	name  string
+	cat   string
+	key   spanKey
+	tid   int64
+	start ObservedEvent
+}
+
+// TraceExporter is an Observer that writes activity of the clock in Chrome trace event format.
+//
+// Timers, tickers and AfterFunc become spans from creation (or previous tick) to fire,
+// Sleep becomes a span on the track of the sleeping goroutine, and Sequence's Wait, Event
+// and context deadlines become instant events. The output can be opened with chrome://tracing
+// or https://ui.perfetto.dev. Timestamps are virtual time relative to the first event.
+// One exporter can observe several clocks.
+type TraceExporter struct {
+	lock       sync.Mutex
+	origin     time.Time
+	last       time.Time
+	events     []traceEvent
+	spans      map[spanKey]*traceSpan
+	sleeps     map[spanKey]*traceSpan
+	goroutines map[int64]bool
+}
+
+// NewTraceExporter creates TraceExporter. Register it by AddObserver of MockTime.
+func NewTraceExporter() *TraceExporter {
+	return &TraceExporter{
+		spans:      make(map[spanKey]*traceSpan),
+		sleeps:     make(map[spanKey]*traceSpan),
+		goroutines: make(map[int64]bool),
+	}
+}
+
+// Observe implements Observer.
+func (t *TraceExporter) Observe(e ObservedEvent) {
+	var tid int64
+	if e.Kind == EventSleep {
+		// Sleep is notified by the sleeping goroutine itself
+		tid = goroutineID()
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.origin.IsZero() {
+		t.origin = e.Virtual
+	}
+	if e.Virtual.After(t.last) {
+		t.last = e.Virtual
+	}
+	key := spanKey{e.Clock, e.ID}
+	switch e.Kind {
+	case EventTimer, EventTicker, EventAfterFunc:
+		t.spans[key] = &traceSpan{name: fmt.Sprintf("%s #%d", e.Kind, e.ID), cat: e.Kind, key: key, start: e}
+	case EventReset:
+		if span, ok := t.spans[key]; ok {
+			t.endSpan(span, e, "reset")
+			t.spans[key] = &traceSpan{name: span.name, cat: span.cat, key: key, start: e}
+		}
+	case EventStop:
+		if span, ok := t.spans[key]; ok {
+			t.endSpan(span, e, "stop")
+			delete(t.spans, key)
+		}
+	case EventFire:
+		if span, ok := t.spans[key]; ok {
+			t.endSpan(span, e, "fire")
+			if span.cat == EventTicker {
+				t.spans[key] = &traceSpan{name: span.name, cat: span.cat, key: key, start: e}
+			} else {
+				delete(t.spans, key)
+			}
+		}
+	case EventSleep:
+		t.goroutines[tid] = true
+		t.sleeps[key] = &traceSpan{name: "sleep", cat: EventSleep, key: key, tid: tid, start: e}
+	case EventSleepEnd:
+		if span, ok := t.sleeps[key]; ok {
+			t.completeSpan(span, e.Virtual, nil)
+			delete(t.sleeps, key)
+		}
+	case EventSequenceWait, EventSequenceEvent, EventDeadline:
+		name := e.Kind
+		if e.Kind != EventDeadline {
+			name = fmt.Sprintf("%s #%d", e.Kind, e.ID)
+		}
+		t.events = append(t.events, traceEvent{
+			Name:  name,
+			Cat:   e.Kind,
+			Ph:    "i",
+			Ts:    t.timestamp(e.Virtual),
+			Scope: "g",
+			Args:  traceArgs(e, nil),
+		})
+	}
+}
+
+// endSpan writes async span of timers. Caller must hold t.lock.
+func (t *TraceExporter) endSpan(span *traceSpan, end ObservedEvent, reason string) {
+	id := strconv.FormatInt(span.key.id, 10)
+	if span.key.clock != 0 {
+		id = fmt.Sprintf("%d.%d", span.key.clock, span.key.id)
+	}
+	t.events = append(t.events, traceEvent{
+		Name: span.name,
+		Cat:  span.cat,
+		Ph:   "b",
+		Ts:   t.timestamp(span.start.Virtual),
+		ID:   id,
+		Args: traceArgs(span.start, nil),
+	}, traceEvent{
+		Name: span.name,
+		Cat:  span.cat,
+		Ph:   "e",
+		Ts:   t.timestamp(end.Virtual),
+		ID:   id,
+		Args: map[string]interface{}{"end": reason},
+	})
+}
+
+// completeSpan writes span of Sleep. Caller must hold t.lock.
+func (t *TraceExporter) completeSpan(span *traceSpan, end time.Time, extra map[string]interface{}) {
+	dur := t.timestamp(end) - t.timestamp(span.start.Virtual)
+	t.events = append(t.events, traceEvent{
+		Name: span.name,
+		Cat:  span.cat,
+		Ph:   "X",
+		Ts:   t.timestamp(span.start.Virtual),
+		Dur:  &dur,
+		Tid:  span.tid,
+		Args: traceArgs(span.start, extra),
+	})
+}
+
+func (t *TraceExporter) timestamp(v time.Time) float64 {
+	return float64(v.Sub(t.origin)) / float64(time.Microsecond)
+}
+
+func traceArgs(e ObservedEvent, extra map[string]interface{}) map[string]interface{} {
+	args := map[string]interface{}{
+		"virtual": e.Virtual.Format(time.RFC3339Nano),
+		"real":    e.Real.Format(time.RFC3339Nano),
+	}
+	if e.Duration != 0 {
+		args["duration"] = e.Duration.String()
+	}
+	for k, v := range e.Labels {
+		args[k] = v
+	}
+	for k, v := range extra {
+		args[k] = v
+	}
+	return args
+}
+
+// WriteTo writes the trace observed so far as JSON.
+//
+// Spans that are not finished yet end at the latest observed time.
+func (t *TraceExporter) WriteTo(w io.Writer) (int64, error) {
+	t.lock.Lock()
+	saved := len(t.events)
+	pending := map[string]interface{}{"end": "pending"}
+	for _, span := range t.spans {
+		t.endSpan(span, ObservedEvent{Virtual: t.last}, "pending")
+	}
+	for _, span := range t.sleeps {
+		t.completeSpan(span, t.last, pending)
+	}
+	events := make([]traceEvent, 0, len(t.events)+len(t.goroutines))
+	for tid := range t.goroutines {
+		events = append(events, traceEvent{
+			Name: "thread_name",
+			Ph:   "M",
+			Tid:  tid,
+			Args: map[string]interface{}{"name": fmt.Sprintf("goroutine %d", tid)},
+		})
+	}
+	events = append(events, t.events...)
+	t.events = t.events[:saved]
+	t.lock.Unlock()
+
+	for i := range events {
+		events[i].Pid = 1
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Ts < events[j].Ts
+	})
+	b, err := json.Marshal(struct {
+		TraceEvents     []traceEvent `json:"traceEvents"`
+		DisplayTimeUnit string       `json:"displayTimeUnit"`
+	}{events, "ms"})
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// goroutineID returns the id of current goroutine. It is used only to split tracks of the trace.
+func goroutineID() int64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseInt(string(b), 10, 64)
+	return id
+}
@@ -0,0 +1,122 @@
+package itime
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type parsedTrace struct {
+	TraceEvents []struct {
+		Name string                 `json:"name"`
+		Cat  string                 `json:"cat"`
+		Ph   string                 `json:"ph"`
+		Ts   float64                `json:"ts"`
+		Dur  float64                `json:"dur"`
+		Tid  int64                  `json:"tid"`
+		ID   string                 `json:"id"`
+		Args map[string]interface{} `json:"args"`
+	} `json:"traceEvents"`
+}
+
+func TestTraceExporter(t *testing.T) {
+	start := time.Date(2017, time.July, 17, 8, 44, 0, 0, time.UTC)
+	mock := NewMockWith(start)
+	defer mock.Close()
+
+	exporter := NewTraceExporter()
+	mock.AddObserver(exporter)
+	mock.SetLabels(map[string]string{"scenario": "trace"})
+
+	timer := mock.NewTimer(3 * time.Second)
+	mock.NewTicker(time.Second)
+
+	started := observed(mock, EventSleep)
+	slept := make(chan struct{})
+	go func() {
+		mock.Sleep(4 * time.Second)
+		close(slept)
+	}()
+	<-started
+	err := NewSequence(Option{
+		Time: mock,
+	}).
+		Wait(2 * time.Second).
+		Event(func() {}).
+		Wait(2 * time.Second).
+		Do(func() {
+			<-slept
+		})
+	assert.NoError(t, err)
+	<-timer.Chan()
+
+	var buf bytes.Buffer
+	_, err = exporter.WriteTo(&buf)
+	assert.NoError(t, err)
+
+	var trace parsedTrace
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &trace))
+
+	var timerSpan, tickerSpans, sleeps, instants []string
+	for _, e := range trace.TraceEvents {
+		switch {
+		case e.Cat == EventTimer:
+			timerSpan = append(timerSpan, e.Ph)
+			if e.Ph == "e" {
+				assert.Equal(t, float64(3*time.Second/time.Microsecond), e.Ts)
+			}
+		case e.Cat == EventTicker && e.Ph == "e":
+			tickerSpans = append(tickerSpans, e.Args["end"].(string))
+		case e.Cat == EventSleep:
+			sleeps = append(sleeps, e.Ph)
+			assert.Equal(t, float64(4*time.Second/time.Microsecond), e.Dur)
+			assert.NotZero(t, e.Tid)
+			assert.Equal(t, "trace", e.Args["scenario"])
+		case e.Ph == "i":
+			instants = append(instants, e.Name)
+		}
+	}
+	assert.Equal(t, []string{"b", "e"}, timerSpan)
+	assert.Equal(t, []string{"fire", "fire", "fire", "fire", "pending"}, tickerSpans)
+	assert.Equal(t, []string{"X"}, sleeps)
+	assert.Equal(t, []string{"wait #1", "event #2", "wait #3"}, instants)
+}
+
+func TestTraceExporter_Clocks(t *testing.T) {
+	first := NewMock()
+	defer first.Close()
+	second := NewMock()
+	defer second.Close()
+
+	exporter := NewTraceExporter()
+	first.AddObserver(exporter)
+	second.AddObserver(exporter)
+
+	// both timers have ID 1 within their own clocks
+	firstTimer := first.NewTimer(time.Second)
+	second.NewTimer(2 * time.Second)
+	first.Advance(time.Second, true)
+	<-firstTimer.Chan()
+
+	var buf bytes.Buffer
+	_, err := exporter.WriteTo(&buf)
+	assert.NoError(t, err)
+	var trace parsedTrace
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &trace))
+
+	ends := make(map[string]string)
+	for _, e := range trace.TraceEvents {
+		if e.Ph == "e" {
+			ends[e.ID] = e.Args["end"].(string)
+		}
+	}
+	assert.Equal(t, 2, len(ends))
+	var reasons []string
+	for _, reason := range ends {
+		reasons = append(reasons, reason)
+	}
+	assert.ElementsMatch(t, []string{"fire", "pending"}, reasons)
+}